@@ -10,6 +10,7 @@ import (
 
 	"github.com/yockii/quick-system/internal/controller"
 	"github.com/yockii/quick-system/internal/initial"
+	"github.com/yockii/quick-system/internal/service"
 )
 
 func main() {
@@ -41,6 +42,8 @@ func main() {
 	authorization.Init()
 	// 初始化数据
 	initial.InitData()
+	// 启动代码生成任务工作池
+	service.GenerationJobService.Start(config.GetInt("generator.workers"))
 
 	// 启动服务
 	controller.InitRouter()
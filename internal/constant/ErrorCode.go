@@ -0,0 +1,10 @@
+package constant
+
+// 本系统的业务错误码，与qscore的通用错误码区分
+const (
+	ErrorCodeLintFailed         = 10001 // 设计校验未通过
+	ErrorCodeRuntimeDataInvalid = 10002 // 运行时数据校验未通过
+	ErrorCodeParentNotFound     = 10003 // 引用的应用或表不存在
+	ErrorCodeParentMismatch     = 10004 // 引用的应用与表不一致，或移动、删除后会破坏一致性
+	ErrorCodeForbidden          = 10005 // 当前用户无权执行该操作
+)
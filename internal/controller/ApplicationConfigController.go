@@ -34,6 +34,9 @@ func (c *applicationConfigController) Add(ctx *fiber.Ctx) error {
 
 	duplicated, success, err := service.ApplicationConfigService.Add(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
@@ -104,6 +107,9 @@ func (c *applicationConfigController) Update(ctx *fiber.Ctx) error {
 	}
 	updated, err := service.ApplicationConfigService.Update(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
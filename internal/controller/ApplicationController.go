@@ -1,11 +1,21 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/yockii/qscore/pkg/constant"
 	"github.com/yockii/qscore/pkg/domain"
 	"github.com/yockii/qscore/pkg/logger"
+	"gopkg.in/yaml.v3"
 
+	qsConstant "github.com/yockii/quick-system/internal/constant"
 	"github.com/yockii/quick-system/internal/model"
 	"github.com/yockii/quick-system/internal/service"
 )
@@ -68,6 +78,8 @@ func (c *applicationController) Add(ctx *fiber.Ctx) error {
 	})
 }
 
+// Delete 级联删除应用及其所有设计、源码包和运行时部署，dryRun=true时仅返回将删除的记录数，
+// dropRuntimeTables=true时同时删除运行时的物理表
 func (c *applicationController) Delete(ctx *fiber.Ctx) error {
 	instance := new(model.Application)
 	if err := ctx.QueryParser(instance); err != nil {
@@ -83,7 +95,7 @@ func (c *applicationController) Delete(ctx *fiber.Ctx) error {
 			Msg:  "ID必须提供",
 		})
 	}
-	deleted, err := service.ApplicationService.Remove(instance)
+	result, err := service.ApplicationService.Remove(instance, ctx.Query("dryRun") == "true", ctx.Query("dropRuntimeTables") == "true")
 	if err != nil {
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
@@ -91,8 +103,8 @@ func (c *applicationController) Delete(ctx *fiber.Ctx) error {
 			Msg:  "服务出现异常",
 		})
 	}
-	if deleted {
-		return ctx.JSON(&domain.CommonResponse{})
+	if result != nil {
+		return ctx.JSON(&domain.CommonResponse{Data: result})
 	}
 	return ctx.JSON(&domain.CommonResponse{
 		Msg:  "无数据被删除",
@@ -205,13 +217,571 @@ func (c *applicationController) GenerateCode(ctx *fiber.Ctx) error {
 			Msg:  "ID必须提供",
 		})
 	}
-	ok, err := service.ApplicationService.GenerateCode(id)
+	req := new(model.GenerateRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(req); err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+	}
+	force := req.Force || ctx.Query("force") == "true"
+	job, lint, err := service.GenerationJobService.Submit(id, req.ReleaseNote, force)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "代码生成任务提交失败!",
+		})
+	}
+	if job == nil {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: qsConstant.ErrorCodeLintFailed,
+			Msg:  "设计校验存在错误，请修正后再生成",
+			Data: lint,
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: job})
+}
+
+func (c *applicationController) GetJob(ctx *fiber.Ctx) error {
+	instance := new(model.GenerationJob)
+	var err error
+	if err = ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	instance, err = service.GenerationJobService.Get(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Data: instance,
+	})
+}
+
+func (c *applicationController) ListJob(ctx *fiber.Ctx) error {
+	applicationId := ctx.Query("applicationId")
+	if applicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
+		})
+	}
+	list, err := service.GenerationJobService.ListByApplication(applicationId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: list})
+}
+
+func (c *applicationController) CancelJob(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	canceled, err := service.GenerationJobService.Cancel(id)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if canceled {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "任务已结束，无法取消",
+		Data: false,
+	})
+}
+
+func (c *applicationController) ListSource(ctx *fiber.Ctx) error {
+	applicationId := ctx.Query("applicationId")
+	if applicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
+		})
+	}
+	list, err := service.ApplicationSourceService.ListByApplication(applicationId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: list})
+}
+
+func (c *applicationController) DownloadSource(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	source, err := service.ApplicationSourceService.Get(&model.ApplicationSource{Id: id})
 	if err != nil {
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
-			Msg:  "代码生成出错!",
+			Msg:  "服务出现异常",
+		})
+	}
+	if source == nil || len(source.Source) == 0 {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeNotFound,
+			Msg:  "源码包不存在",
+		})
+	}
+	application, err := service.ApplicationService.Get(&model.Application{Id: source.ApplicationId})
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	pkg := ""
+	if application != nil {
+		pkg = application.Package
+	}
+	ctx.Attachment(sourceArchiveName(pkg, source.Version))
+	ctx.Set(fiber.HeaderContentType, "application/zip")
+	return ctx.Send(source.Source)
+}
+
+var archiveNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// sourceArchiveName 以包名最后一段及版本号作为源码包的下载文件名
+func sourceArchiveName(pkg string, version int) string {
+	name := archiveNameInvalidChars.ReplaceAllString(path.Base(pkg), "_")
+	if name == "" || name == "." || name == "_" {
+		name = "source"
+	}
+	if version > 0 {
+		name += "-v" + strconv.Itoa(version)
+	}
+	return name + ".zip"
+}
+
+func (c *applicationController) DiffSource(ctx *fiber.Ctx) error {
+	applicationId := ctx.Query("applicationId")
+	if applicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
 		})
 	}
-	return ctx.JSON(&domain.CommonResponse{Data: ok})
+	from, err := strconv.Atoi(ctx.Query("from"))
+	if err != nil {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	to, err := strconv.Atoi(ctx.Query("to"))
+	if err != nil {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	diffs, err := service.ApplicationSourceService.Diff(applicationId, from, to)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: diffs})
+}
+
+func (c *applicationController) ExportManifest(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	manifest, err := service.ApplicationManifestService.Export(id)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	name := strings.TrimSuffix(sourceArchiveName(manifest.Application.Package, 0), ".zip")
+	if ctx.Query("format") == "yaml" {
+		bs, err := yaml.Marshal(manifest)
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeService,
+				Msg:  "服务出现异常",
+			})
+		}
+		ctx.Attachment(name + ".manifest.yaml")
+		ctx.Set(fiber.HeaderContentType, "application/x-yaml")
+		return ctx.Send(bs)
+	}
+	bs, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	ctx.Attachment(name + ".manifest.json")
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(bs)
+}
+
+// ExportOpenApi 导出应用的OpenAPI 3接口文档，默认json格式，format=yaml时导出yaml
+func (c *applicationController) ExportOpenApi(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	doc, err := service.OpenApiService.Export(id)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if ctx.Query("format") == "yaml" {
+		bs, err := yaml.Marshal(doc)
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeService,
+				Msg:  "服务出现异常",
+			})
+		}
+		ctx.Attachment("openapi.yaml")
+		ctx.Set(fiber.HeaderContentType, "application/x-yaml")
+		return ctx.Send(bs)
+	}
+	bs, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	ctx.Attachment("openapi.json")
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(bs)
+}
+
+// ImportManifest 导入应用定义清单，清单可作为请求体或以file字段上传，yaml格式通过format=yaml、Content-Type或文件扩展名识别
+func (c *applicationController) ImportManifest(ctx *fiber.Ctx) error {
+	mode := ctx.Query("mode", model.ManifestImportCreate)
+	content := ctx.Body()
+	isYaml := ctx.Query("format") == "yaml" || strings.Contains(ctx.Get(fiber.HeaderContentType), "yaml")
+	if fh, err := ctx.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+		content, err = io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+		ext := strings.ToLower(path.Ext(fh.Filename))
+		isYaml = isYaml || ext == ".yaml" || ext == ".yml"
+	}
+	manifest := new(model.ApplicationManifest)
+	var err error
+	if isYaml {
+		err = yaml.Unmarshal(content, manifest)
+	} else {
+		err = json.Unmarshal(content, manifest)
+	}
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	ownerId := ""
+	if uidPtr := ctx.Locals("userId"); uidPtr != nil {
+		ownerId = uidPtr.(string)
+	}
+	result, err := service.ApplicationManifestService.Import(manifest, mode, ctx.Query("applicationId"), ownerId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if len(result.Conflicts) > 0 {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "清单存在冲突",
+			Data: result,
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
+
+func (c *applicationController) Clone(ctx *fiber.Ctx) error {
+	req := new(model.ApplicationCloneRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if req.Id == "" || req.AppName == "" || req.Package == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID/应用名/包名必须提供",
+		})
+	}
+	ownerId := ""
+	if uidPtr := ctx.Locals("userId"); uidPtr != nil {
+		ownerId = uidPtr.(string)
+	}
+	duplicated, instance, err := service.ApplicationService.Clone(req, ownerId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if duplicated {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "有重复记录",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: instance})
+}
+
+func (c *applicationController) Lint(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	result, err := service.LintService.Lint(id)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
+
+// MigrationScript 生成两个设计版本之间的数据库迁移脚本。
+// from为空时取最近一次生成的版本，为-1时从空数据库开始；to为空时取当前设计；format=sql时直接下载脚本
+func (c *applicationController) MigrationScript(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	var from, to int
+	var err error
+	if v := ctx.Query("from"); v != "" {
+		if from, err = strconv.Atoi(v); err != nil {
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+	}
+	if v := ctx.Query("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+	}
+	script, err := service.MigrationService.Generate(id, from, to, ctx.Query("dialect", model.SqlDialectMysql))
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if ctx.Query("format") == "sql" {
+		ctx.Attachment(fmt.Sprintf("migration_v%d_v%d.%s.sql", script.FromVersion, script.ToVersion, script.Dialect))
+		ctx.Set(fiber.HeaderContentType, "application/sql")
+		return ctx.SendString(script.Script)
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: script})
+}
+
+// MockData 生成应用(指定tableId时为单表)的模拟数据，format为sql/csv/json，seed相同时生成的数据相同
+func (c *applicationController) MockData(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	var rows int
+	var seed int64
+	var err error
+	if v := ctx.Query("rows"); v != "" {
+		if rows, err = strconv.Atoi(v); err != nil {
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+	}
+	if v := ctx.Query("seed"); v != "" {
+		if seed, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+	}
+	set, err := service.MockDataService.Generate(id, ctx.Query("tableId"), rows, seed)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	bs, ext, err := service.MockDataService.Render(set, ctx.Query("format", model.MockFormatSql), ctx.Query("dialect", model.SqlDialectMysql))
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	contentTypes := map[string]string{
+		"sql":  "application/sql",
+		"csv":  "text/csv",
+		"zip":  "application/zip",
+		"json": fiber.MIMEApplicationJSON,
+	}
+	ctx.Attachment(fmt.Sprintf("mock_%d.%s", set.Seed, ext))
+	ctx.Set(fiber.HeaderContentType, contentTypes[ext])
+	ctx.Set("X-Mock-Seed", strconv.FormatInt(set.Seed, 10))
+	return ctx.Send(bs)
+}
+
+// ErDiagram 生成应用的ER图文本，format为mermaid/plantuml/dot，tables为逗号分隔的表ID或表名，
+// hideAudit=true时隐藏记录时间字段，download=true时作为文件下载
+func (c *applicationController) ErDiagram(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	options := &model.ErDiagramOptions{
+		Format:    ctx.Query("format", model.ErFormatMermaid),
+		HideAudit: ctx.Query("hideAudit") == "true",
+	}
+	if tables := ctx.Query("tables"); tables != "" {
+		options.Tables = strings.Split(tables, ",")
+	}
+	diagram, err := service.ErDiagramService.Render(id, options)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if ctx.Query("download") == "true" {
+		extensions := map[string]string{
+			model.ErFormatMermaid:  "mmd",
+			model.ErFormatPlantUml: "puml",
+			model.ErFormatDot:      "dot",
+		}
+		ctx.Attachment("er." + extensions[options.Format])
+	}
+	ctx.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	return ctx.SendString(diagram)
+}
+
+// DataDictionary 导出应用的数据字典文档，format为markdown/html/xlsx
+func (c *applicationController) DataDictionary(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	bs, ext, err := service.DataDictionaryService.Export(id, ctx.Query("format", model.DictionaryFormatMarkdown))
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	contentTypes := map[string]string{
+		"md":   "text/markdown; charset=utf-8",
+		"html": "text/html; charset=utf-8",
+		"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}
+	ctx.Attachment("data_dictionary." + ext)
+	ctx.Set(fiber.HeaderContentType, contentTypes[ext])
+	return ctx.Send(bs)
 }
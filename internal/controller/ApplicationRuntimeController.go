@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/constant"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+
+	qsConstant "github.com/yockii/quick-system/internal/constant"
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/service"
+)
+
+var ApplicationRuntimeController = new(applicationRuntimeController)
+
+type applicationRuntimeController struct{}
+
+// Deploy 按当前表设计部署应用的运行时模式，存在破坏性变更时需force=true
+func (c *applicationRuntimeController) Deploy(ctx *fiber.Ctx) error {
+	req := new(model.RuntimeDeployRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if req.ApplicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
+		})
+	}
+	req.Force = req.Force || ctx.Query("force") == "true"
+
+	ownerId := ""
+	if uidPtr := ctx.Locals("userId"); uidPtr != nil {
+		ownerId = uidPtr.(string)
+	}
+	result, lint, err := service.ApplicationRuntimeService.Deploy(req, ownerId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if lint != nil {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: qsConstant.ErrorCodeLintFailed,
+			Msg:  "设计校验存在错误，请修正后再部署",
+			Data: lint,
+		})
+	}
+	if !result.Applied {
+		return ctx.JSON(&domain.CommonResponse{
+			Msg:  "表设计的变更存在破坏性操作，确认后以force=true重新部署",
+			Data: result,
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
+
+func (c *applicationRuntimeController) Start(ctx *fiber.Ctx) error {
+	applicationId := ctx.Query("applicationId")
+	if applicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
+		})
+	}
+	started, err := service.ApplicationRuntimeService.Start(applicationId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if !started {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeNotFound,
+			Msg:  "应用尚未部署",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{})
+}
+
+func (c *applicationRuntimeController) Stop(ctx *fiber.Ctx) error {
+	applicationId := ctx.Query("applicationId")
+	if applicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
+		})
+	}
+	stopped, err := service.ApplicationRuntimeService.Stop(applicationId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if !stopped {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeNotFound,
+			Msg:  "应用尚未部署",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{})
+}
+
+// Delete 删除运行时部署，dropTables=true时同时删除物理表及数据
+func (c *applicationRuntimeController) Delete(ctx *fiber.Ctx) error {
+	applicationId := ctx.Query("applicationId")
+	if applicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID必须提供",
+		})
+	}
+	deleted, err := service.ApplicationRuntimeService.Remove(applicationId, ctx.Query("dropTables") == "true")
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if deleted {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被删除",
+		Data: false,
+	})
+}
+
+func (c *applicationRuntimeController) Paginate(ctx *fiber.Ctx) error {
+	pr := new(model.ApplicationRuntimeRequest)
+	if err := ctx.QueryParser(pr); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	limit, offset, orderBy, err := parsePaginationInfoFromQuery(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	timeRangeMap := make(map[string]*domain.TimeCondition)
+	if pr.CreateTimeRange != nil {
+		timeRangeMap["create_time"] = &domain.TimeCondition{
+			Start: pr.CreateTimeRange.Start,
+			End:   pr.CreateTimeRange.End,
+		}
+	}
+
+	total, list, err := service.ApplicationRuntimeService.PaginateBetweenTimes(pr.ApplicationRuntime, limit, offset, orderBy, timeRangeMap)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: &domain.Paginate{
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+		Items:  list,
+	}})
+}
+
+func (c *applicationRuntimeController) Get(ctx *fiber.Ctx) error {
+	instance := new(model.ApplicationRuntime)
+	var err error
+	if err = ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" && instance.ApplicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID或应用ID必须提供",
+		})
+	}
+	instance, err = service.ApplicationRuntimeService.Get(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Data: instance,
+	})
+}
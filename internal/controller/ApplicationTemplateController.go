@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/constant"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/service"
+)
+
+var ApplicationTemplateController = new(applicationTemplateController)
+
+type applicationTemplateController struct{}
+
+// Add 将已有应用的设计保存为模板
+func (c *applicationTemplateController) Add(ctx *fiber.Ctx) error {
+	req := new(model.SaveTemplateRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	// 处理必填
+	if req.ApplicationId == "" || req.TemplateName == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "应用ID/模板名称必须提供",
+		})
+	}
+
+	ownerId := ""
+	if uidPtr := ctx.Locals("userId"); uidPtr != nil {
+		ownerId = uidPtr.(string)
+	}
+	duplicated, instance, err := service.ApplicationTemplateService.SaveFromApplication(req, ownerId)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if duplicated {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "有重复记录",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: instance})
+}
+
+func (c *applicationTemplateController) Delete(ctx *fiber.Ctx) error {
+	instance := new(model.ApplicationTemplate)
+	if err := ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	deleted, err := service.ApplicationTemplateService.Remove(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if deleted {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被删除",
+		Data: false,
+	})
+}
+
+func (c *applicationTemplateController) Update(ctx *fiber.Ctx) error {
+	instance := new(model.ApplicationTemplate)
+	if err := ctx.BodyParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	updated, err := service.ApplicationTemplateService.Update(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if updated {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被更新",
+		Data: false,
+	})
+}
+
+func (c *applicationTemplateController) Paginate(ctx *fiber.Ctx) error {
+	pr := new(model.ApplicationTemplateRequest)
+	if err := ctx.QueryParser(pr); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	limit, offset, orderBy, err := parsePaginationInfoFromQuery(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	timeRangeMap := make(map[string]*domain.TimeCondition)
+	if pr.CreateTimeRange != nil {
+		timeRangeMap["create_time"] = &domain.TimeCondition{
+			Start: pr.CreateTimeRange.Start,
+			End:   pr.CreateTimeRange.End,
+		}
+	}
+
+	total, list, err := service.ApplicationTemplateService.PaginateBetweenTimes(pr.ApplicationTemplate, limit, offset, orderBy, timeRangeMap)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: &domain.Paginate{
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+		Items:  list,
+	}})
+}
+
+func (c *applicationTemplateController) Get(ctx *fiber.Ctx) error {
+	instance := new(model.ApplicationTemplate)
+	var err error
+	if err = ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	instance, err = service.ApplicationTemplateService.Get(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Data: instance,
+	})
+}
@@ -0,0 +1,266 @@
+package controller
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/constant"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/service"
+)
+
+var CodeTemplateController = new(codeTemplateController)
+
+type codeTemplateController struct{}
+
+func (c *codeTemplateController) Add(ctx *fiber.Ctx) error {
+	instance := new(model.CodeTemplate)
+	if err := ctx.BodyParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	// 模板文件以file字段上传时优先使用
+	if fh, err := ctx.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+		bs, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+		instance.Content = string(bs)
+	}
+
+	// 处理必填
+	if instance.ApplicationId == "" || instance.Kind == "" || instance.Content == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用/文件类型/模板内容必须提供",
+		})
+	}
+	if uidPtr := ctx.Locals("userId"); uidPtr != nil {
+		instance.OwnerId = uidPtr.(string)
+	}
+
+	duplicated, success, err := service.CodeTemplateService.Add(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if duplicated {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "与最新版本的内容相同",
+		})
+	}
+	if success {
+		return ctx.JSON(&domain.CommonResponse{Data: instance})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Code: constant.ErrorCodeUnknown,
+		Msg:  "服务出现异常",
+	})
+}
+
+func (c *codeTemplateController) Delete(ctx *fiber.Ctx) error {
+	instance := new(model.CodeTemplate)
+	if err := ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	deleted, err := service.CodeTemplateService.Remove(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if deleted {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被删除",
+		Data: false,
+	})
+}
+
+func (c *codeTemplateController) Update(ctx *fiber.Ctx) error {
+	instance := new(model.CodeTemplate)
+	if err := ctx.BodyParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	updated, err := service.CodeTemplateService.Update(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if updated {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被更新",
+		Data: false,
+	})
+}
+
+func (c *codeTemplateController) Paginate(ctx *fiber.Ctx) error {
+	pr := new(model.CodeTemplateRequest)
+	if err := ctx.QueryParser(pr); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	limit, offset, orderBy, err := parsePaginationInfoFromQuery(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	timeRangeMap := make(map[string]*domain.TimeCondition)
+	if pr.CreateTimeRange != nil {
+		timeRangeMap["create_time"] = &domain.TimeCondition{
+			Start: pr.CreateTimeRange.Start,
+			End:   pr.CreateTimeRange.End,
+		}
+	}
+
+	total, list, err := service.CodeTemplateService.PaginateBetweenTimes(pr.CodeTemplate, limit, offset, orderBy, timeRangeMap)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: &domain.Paginate{
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+		Items:  list,
+	}})
+}
+
+func (c *codeTemplateController) Get(ctx *fiber.Ctx) error {
+	instance := new(model.CodeTemplate)
+	var err error
+	if err = ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	instance, err = service.CodeTemplateService.Get(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Data: instance,
+	})
+}
+
+// Reset 删除某文件类型的全部自定义版本
+func (c *codeTemplateController) Reset(ctx *fiber.Ctx) error {
+	instance := new(model.CodeTemplate)
+	if err := ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.ApplicationId == "" || instance.Kind == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用/文件类型必须提供",
+		})
+	}
+	deleted, err := service.CodeTemplateService.Reset(instance.ApplicationId, instance.Kind)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: deleted})
+}
+
+// Preview 以一张表渲染模板，模板错误在结果的error中返回
+func (c *codeTemplateController) Preview(ctx *fiber.Ctx) error {
+	req := new(model.CodeTemplatePreviewRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if req.ApplicationId == "" || req.TableId == "" || req.Kind == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用/表/文件类型必须提供",
+		})
+	}
+	result, err := service.CodeTemplateService.Preview(req)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
@@ -34,6 +34,9 @@ func (c *columnConfigController) Add(ctx *fiber.Ctx) error {
 
 	duplicated, success, err := service.ColumnConfigService.Add(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
@@ -72,6 +75,9 @@ func (c *columnConfigController) Delete(ctx *fiber.Ctx) error {
 	}
 	deleted, err := service.ColumnConfigService.Remove(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
@@ -104,6 +110,9 @@ func (c *columnConfigController) Update(ctx *fiber.Ctx) error {
 	}
 	updated, err := service.ColumnConfigService.Update(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
@@ -183,3 +192,34 @@ func (c *columnConfigController) Get(ctx *fiber.Ctx) error {
 		Data: instance,
 	})
 }
+
+// ListTypes 列出支持的字段类型及其Go类型、前端控件
+func (c *columnConfigController) ListTypes(ctx *fiber.Ctx) error {
+	return ctx.JSON(&domain.CommonResponse{Data: model.ColumnTypeSpecs})
+}
+
+// Reorder 按提交的ID顺序重新排列表内的字段
+func (c *columnConfigController) Reorder(ctx *fiber.Ctx) error {
+	req := new(model.ReorderRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if req.TableId == "" || len(req.Ids) == 0 {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属表/排序列表必须提供",
+		})
+	}
+	if err := service.ColumnConfigService.Reorder(req.TableId, req.Ids); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{})
+}
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/domain"
 	"github.com/yockii/qscore/pkg/server"
 	"github.com/yockii/qscore/pkg/util"
+
+	qsConstant "github.com/yockii/quick-system/internal/constant"
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/service"
 )
 
 func InitRouter() {
@@ -30,17 +36,64 @@ func InitRouter() {
 	//applicationConfig.Get("/instance", ApplicationConfigController.Get)
 
 	// Application
-	server.StandardRouter(
+	application := server.StandardRouter(
 		"/application",
 		ApplicationController.Add,
 		ApplicationController.Update,
 		ApplicationController.Delete,
 		ApplicationController.Get,
 		ApplicationController.Paginate,
-	).Post("/generate/:id", ApplicationController.GenerateCode)
+	)
+	application.Post("/generate/:id", ApplicationController.GenerateCode)
+	application.Get("/job/instance", ApplicationController.GetJob)
+	application.Get("/job/list", ApplicationController.ListJob)
+	application.Post("/job/cancel/:id", ApplicationController.CancelJob)
+	application.Get("/source/list", ApplicationController.ListSource)
+	application.Get("/source/download", ApplicationController.DownloadSource)
+	application.Get("/source/diff", ApplicationController.DiffSource)
+	application.Get("/export", ApplicationController.ExportManifest)
+	application.Get("/openapi", ApplicationController.ExportOpenApi)
+	application.Post("/import", ApplicationController.ImportManifest)
+	application.Post("/clone", ApplicationController.Clone)
+	application.Get("/lint", ApplicationController.Lint)
+	application.Get("/migration", ApplicationController.MigrationScript)
+	application.Get("/mock", ApplicationController.MockData)
+	application.Get("/er", ApplicationController.ErDiagram)
+	application.Get("/dictionary", ApplicationController.DataDictionary)
 
-	// ColumnConfig
+	// ApplicationRuntime
+	applicationRuntime := server.Group("/applicationRuntime", true, true)
+	applicationRuntime.Post("/deploy", ApplicationRuntimeController.Deploy)
+	applicationRuntime.Post("/start", ApplicationRuntimeController.Start)
+	applicationRuntime.Post("/stop", ApplicationRuntimeController.Stop)
+	applicationRuntime.Delete("/", ApplicationRuntimeController.Delete)
+	applicationRuntime.Get("/list", ApplicationRuntimeController.Paginate)
+	applicationRuntime.Get("/instance", ApplicationRuntimeController.Get)
+
+	// ApplicationTemplate
 	server.StandardRouter(
+		"/applicationTemplate",
+		ApplicationTemplateController.Add,
+		ApplicationTemplateController.Update,
+		ApplicationTemplateController.Delete,
+		ApplicationTemplateController.Get,
+		ApplicationTemplateController.Paginate,
+	)
+
+	// CodeTemplate
+	codeTemplate := server.StandardRouter(
+		"/codeTemplate",
+		CodeTemplateController.Add,
+		CodeTemplateController.Update,
+		CodeTemplateController.Delete,
+		CodeTemplateController.Get,
+		CodeTemplateController.Paginate,
+	)
+	codeTemplate.Post("/reset", CodeTemplateController.Reset)
+	codeTemplate.Post("/preview", CodeTemplateController.Preview)
+
+	// ColumnConfig
+	columnConfig := server.StandardRouter(
 		"/columnConfig",
 		ColumnConfigController.Add,
 		ColumnConfigController.Update,
@@ -48,6 +101,8 @@ func InitRouter() {
 		ColumnConfigController.Get,
 		ColumnConfigController.Paginate,
 	)
+	columnConfig.Get("/types", ColumnConfigController.ListTypes)
+	columnConfig.Post("/reorder", ColumnConfigController.Reorder)
 
 	// Dict
 	server.StandardRouter(
@@ -58,6 +113,11 @@ func InitRouter() {
 		DictController.Get,
 		DictController.Paginate,
 	)
+	// Maintenance 维护接口
+	maintenance := server.Group("/maintenance", true, true)
+	maintenance.Get("/orphans", MaintenanceController.FindOrphans)
+	maintenance.Delete("/orphans", MaintenanceController.PurgeOrphans)
+
 	// Resource
 	server.StandardRouter(
 		"/resource",
@@ -77,7 +137,7 @@ func InitRouter() {
 		RoleController.Paginate,
 	)
 	// TableConfig
-	server.StandardRouter(
+	tableConfig := server.StandardRouter(
 		"/tableConfig",
 		TableConfigController.Add,
 		TableConfigController.Update,
@@ -85,6 +145,27 @@ func InitRouter() {
 		TableConfigController.Get,
 		TableConfigController.Paginate,
 	)
+	tableConfig.Post("/import/database", TableConfigController.ImportFromDatabase)
+	tableConfig.Post("/import/ddl", TableConfigController.ImportFromDdl)
+	tableConfig.Post("/reorder", TableConfigController.Reorder)
+	// TableRelation
+	server.StandardRouter(
+		"/tableRelation",
+		TableRelationController.Add,
+		TableRelationController.Update,
+		TableRelationController.Delete,
+		TableRelationController.Get,
+		TableRelationController.Paginate,
+	)
+	// TableIndex
+	server.StandardRouter(
+		"/tableIndex",
+		TableIndexController.Add,
+		TableIndexController.Update,
+		TableIndexController.Delete,
+		TableIndexController.Get,
+		TableIndexController.Paginate,
+	)
 	// User
 	server.StandardRouter(
 		"/user",
@@ -94,6 +175,15 @@ func InitRouter() {
 		UserController.Get,
 		UserController.Paginate,
 	)
+
+	// 运行时模式的数据接口，表由运行时部署动态注册，不校验资源权限
+	runtime := server.Group(model.RuntimeRoutePrefix, true, false)
+	runtime.Post("/:prefix/:table", RuntimeDataController.Add)
+	runtime.Put("/:prefix/:table", RuntimeDataController.Update)
+	runtime.Delete("/:prefix/:table", RuntimeDataController.Delete)
+	runtime.Get("/:prefix/:table/instance", RuntimeDataController.Get)
+	runtime.Get("/:prefix/:table/list", RuntimeDataController.Paginate)
+	runtime.Put("/:prefix/:table/:field", RuntimeDataController.UpdateField)
 }
 
 func parsePaginationInfoFromQuery(ctx *fiber.Ctx) (size, offset int, orderBy string, err error) {
@@ -131,3 +221,19 @@ func parsePaginationInfoFromQuery(ctx *fiber.Ctx) (size, offset int, orderBy str
 	}
 	return
 }
+
+// referenceErrorResponse 引用校验未通过时返回对应错误码及原因，其他错误返回nil
+func referenceErrorResponse(err error) *domain.CommonResponse {
+	var re *service.ReferenceError
+	if !errors.As(err, &re) {
+		return nil
+	}
+	code := qsConstant.ErrorCodeParentMismatch
+	if errors.Is(err, service.ErrParentNotFound) {
+		code = qsConstant.ErrorCodeParentNotFound
+	}
+	return &domain.CommonResponse{
+		Code: code,
+		Msg:  re.Msg,
+	}
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/constant"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+
+	"github.com/yockii/quick-system/internal/service"
+)
+
+var MaintenanceController = new(maintenanceController)
+
+type maintenanceController struct{}
+
+// FindOrphans 统计所属应用或所属表已不存在的孤立记录
+func (c *maintenanceController) FindOrphans(ctx *fiber.Ctx) error {
+	return c.purgeOrphans(ctx, true)
+}
+
+// PurgeOrphans 清理孤立记录，dryRun=true时仅统计
+func (c *maintenanceController) PurgeOrphans(ctx *fiber.Ctx) error {
+	return c.purgeOrphans(ctx, ctx.Query("dryRun") == "true")
+}
+
+func (c *maintenanceController) purgeOrphans(ctx *fiber.Ctx, dryRun bool) error {
+	result, err := service.MaintenanceService.PurgeOrphans(dryRun)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/constant"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+
+	qsConstant "github.com/yockii/quick-system/internal/constant"
+	"github.com/yockii/quick-system/internal/service"
+)
+
+// RuntimeDataController 运行时模式的数据接口，路由为 /runtime/:prefix/:table ，与生成代码的接口一致
+var RuntimeDataController = new(runtimeDataController)
+
+type runtimeDataController struct{}
+
+// parseRuntimeBody 解析json请求体，数字保留为json.Number以免大整数丢失精度
+func parseRuntimeBody(ctx *fiber.Ctx) (map[string]interface{}, error) {
+	body := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(ctx.Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// runtimeErrorResponse 数据校验错误返回具体原因，其余错误按服务异常处理
+func runtimeErrorResponse(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, service.ErrRuntimeNotFound) {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeNotFound,
+			Msg:  err.Error(),
+		})
+	}
+	var de *service.RuntimeDataError
+	if errors.As(err, &de) {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: qsConstant.ErrorCodeRuntimeDataInvalid,
+			Msg:  de.Msg,
+		})
+	}
+	logger.Error(err)
+	return ctx.JSON(&domain.CommonResponse{
+		Code: constant.ErrorCodeService,
+		Msg:  "服务出现异常",
+	})
+}
+
+func (c *runtimeDataController) Add(ctx *fiber.Ctx) error {
+	body, err := parseRuntimeBody(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	duplicated, record, err := service.RuntimeDataService.Add(ctx.Params("prefix"), ctx.Params("table"), body)
+	if err != nil {
+		return runtimeErrorResponse(ctx, err)
+	}
+	if duplicated {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "有重复记录",
+		})
+	}
+	if record != nil {
+		return ctx.JSON(&domain.CommonResponse{Data: record})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Code: constant.ErrorCodeUnknown,
+		Msg:  "服务出现异常",
+	})
+}
+
+func (c *runtimeDataController) Delete(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	deleted, err := service.RuntimeDataService.Remove(ctx.Params("prefix"), ctx.Params("table"), id)
+	if err != nil {
+		return runtimeErrorResponse(ctx, err)
+	}
+	if deleted {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被删除",
+		Data: false,
+	})
+}
+
+func (c *runtimeDataController) Update(ctx *fiber.Ctx) error {
+	body, err := parseRuntimeBody(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	duplicated, success, err := service.RuntimeDataService.Update(ctx.Params("prefix"), ctx.Params("table"), body)
+	return c.updateResponse(ctx, duplicated, success, err)
+}
+
+// UpdateField 更改独立更改的字段，路由为 /runtime/:prefix/:table/:field
+func (c *runtimeDataController) UpdateField(ctx *fiber.Ctx) error {
+	body, err := parseRuntimeBody(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	duplicated, success, err := service.RuntimeDataService.UpdateField(ctx.Params("prefix"), ctx.Params("table"), ctx.Params("field"), body)
+	return c.updateResponse(ctx, duplicated, success, err)
+}
+
+func (c *runtimeDataController) updateResponse(ctx *fiber.Ctx, duplicated, success bool, err error) error {
+	if err != nil {
+		return runtimeErrorResponse(ctx, err)
+	}
+	if duplicated {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "有重复记录",
+		})
+	}
+	if success {
+		return ctx.JSON(&domain.CommonResponse{Data: true})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被更新",
+		Data: false,
+	})
+}
+
+func (c *runtimeDataController) Paginate(ctx *fiber.Ctx) error {
+	limit, offset, _, err := parsePaginationInfoFromQuery(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	total, list, err := service.RuntimeDataService.Paginate(ctx.Params("prefix"), ctx.Params("table"), ctx.Query, limit, offset, ctx.Query("orderBy"))
+	if err != nil {
+		return runtimeErrorResponse(ctx, err)
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: &domain.Paginate{
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+		Items:  list,
+	}})
+}
+
+func (c *runtimeDataController) Get(ctx *fiber.Ctx) error {
+	id := ctx.Query("id")
+	if id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	record, err := service.RuntimeDataService.Get(ctx.Params("prefix"), ctx.Params("table"), id)
+	if err != nil {
+		return runtimeErrorResponse(ctx, err)
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Data: record,
+	})
+}
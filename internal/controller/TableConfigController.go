@@ -1,11 +1,16 @@
 package controller
 
 import (
+	"errors"
+	"io"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/authorization"
 	"github.com/yockii/qscore/pkg/constant"
 	"github.com/yockii/qscore/pkg/domain"
 	"github.com/yockii/qscore/pkg/logger"
 
+	qsConstant "github.com/yockii/quick-system/internal/constant"
 	"github.com/yockii/quick-system/internal/model"
 	"github.com/yockii/quick-system/internal/service"
 )
@@ -34,6 +39,9 @@ func (c *tableConfigController) Add(ctx *fiber.Ctx) error {
 
 	duplicated, success, err := service.TableConfigService.Add(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
@@ -55,6 +63,7 @@ func (c *tableConfigController) Add(ctx *fiber.Ctx) error {
 	})
 }
 
+// Delete 级联删除表及其字段、索引和表关联，dryRun=true时仅返回将删除的记录数
 func (c *tableConfigController) Delete(ctx *fiber.Ctx) error {
 	instance := new(model.TableConfig)
 	if err := ctx.QueryParser(instance); err != nil {
@@ -70,7 +79,7 @@ func (c *tableConfigController) Delete(ctx *fiber.Ctx) error {
 			Msg:  "ID必须提供",
 		})
 	}
-	deleted, err := service.TableConfigService.Remove(instance)
+	result, err := service.TableConfigService.Remove(instance, ctx.Query("dryRun") == "true")
 	if err != nil {
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
@@ -78,8 +87,8 @@ func (c *tableConfigController) Delete(ctx *fiber.Ctx) error {
 			Msg:  "服务出现异常",
 		})
 	}
-	if deleted {
-		return ctx.JSON(&domain.CommonResponse{})
+	if result != nil {
+		return ctx.JSON(&domain.CommonResponse{Data: result})
 	}
 	return ctx.JSON(&domain.CommonResponse{
 		Msg:  "无数据被删除",
@@ -104,6 +113,9 @@ func (c *tableConfigController) Update(ctx *fiber.Ctx) error {
 	}
 	updated, err := service.TableConfigService.Update(instance)
 	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
 		logger.Error(err)
 		return ctx.JSON(&domain.CommonResponse{
 			Code: constant.ErrorCodeService,
@@ -183,3 +195,135 @@ func (c *tableConfigController) Get(ctx *fiber.Ctx) error {
 		Data: instance,
 	})
 }
+
+func (c *tableConfigController) ImportFromDatabase(ctx *fiber.Ctx) error {
+	req := new(model.SchemaImportRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if req.ApplicationId == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用必须提供",
+		})
+	}
+	if req.Dsn != "" {
+		// 直接指定连接串可访问任意数据库，仅超级管理员可用
+		uid := ""
+		if uidPtr := ctx.Locals("userId"); uidPtr != nil {
+			uid = uidPtr.(string)
+		}
+		isSuperAdmin := false
+		if uid != "" {
+			var err error
+			if isSuperAdmin, _, err = authorization.GetSubjectResourceIds(uid, ""); err != nil {
+				logger.Error(err)
+				return ctx.JSON(&domain.CommonResponse{
+					Code: constant.ErrorCodeService,
+					Msg:  "服务出现异常",
+				})
+			}
+		}
+		if !isSuperAdmin {
+			return ctx.JSON(&domain.CommonResponse{
+				Code: qsConstant.ErrorCodeForbidden,
+				Msg:  "仅超级管理员可以直接指定数据源连接串",
+			})
+		}
+	}
+	result, err := service.SchemaImportService.ImportFromDatabase(req)
+	if errors.Is(err, service.ErrImportSourceNotFound) {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeNotFound,
+			Msg:  err.Error(),
+		})
+	}
+	if resp := referenceErrorResponse(err); resp != nil {
+		return ctx.JSON(resp)
+	}
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
+
+func (c *tableConfigController) ImportFromDdl(ctx *fiber.Ctx) error {
+	req := new(model.DdlImportRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	// 脚本文件以file字段上传时优先使用
+	if fh, err := ctx.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+		bs, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			logger.Error(err)
+			return ctx.JSON(&domain.CommonResponse{
+				Code: constant.ErrorCodeBodyParse,
+				Msg:  "参数解析失败!",
+			})
+		}
+		req.Script = string(bs)
+	}
+	if req.ApplicationId == "" || req.Script == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用/建表脚本必须提供",
+		})
+	}
+	result, err := service.DdlImportService.ImportFromScript(req)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: result})
+}
+
+// Reorder 按提交的ID顺序重新排列应用内的表
+func (c *tableConfigController) Reorder(ctx *fiber.Ctx) error {
+	req := new(model.ReorderRequest)
+	if err := ctx.BodyParser(req); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if req.ApplicationId == "" || len(req.Ids) == 0 {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用/排序列表必须提供",
+		})
+	}
+	if err := service.TableConfigService.Reorder(req.ApplicationId, req.Ids); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{})
+}
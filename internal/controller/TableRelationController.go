@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yockii/qscore/pkg/constant"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/service"
+)
+
+var TableRelationController = new(tableRelationController)
+
+type tableRelationController struct{}
+
+func (c *tableRelationController) Add(ctx *fiber.Ctx) error {
+	instance := new(model.TableRelation)
+	if err := ctx.BodyParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	// 处理必填
+	if instance.ApplicationId == "" || instance.SourceTableId == "" || instance.TargetTableId == "" || instance.Cardinality == 0 {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "所属应用/源表/目标表/关联类型必须提供",
+		})
+	}
+
+	duplicated, success, err := service.TableRelationService.Add(instance)
+	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if duplicated {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeDuplicate,
+			Msg:  "有重复记录",
+		})
+	}
+	if success {
+		return ctx.JSON(&domain.CommonResponse{Data: instance})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Code: constant.ErrorCodeUnknown,
+		Msg:  "服务出现异常",
+	})
+}
+
+func (c *tableRelationController) Delete(ctx *fiber.Ctx) error {
+	instance := new(model.TableRelation)
+	if err := ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	deleted, err := service.TableRelationService.Remove(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if deleted {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被删除",
+		Data: false,
+	})
+}
+
+func (c *tableRelationController) Update(ctx *fiber.Ctx) error {
+	instance := new(model.TableRelation)
+	if err := ctx.BodyParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	if instance.Id == "" {
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeLackOfField,
+			Msg:  "ID必须提供",
+		})
+	}
+	updated, err := service.TableRelationService.Update(instance)
+	if err != nil {
+		if resp := referenceErrorResponse(err); resp != nil {
+			return ctx.JSON(resp)
+		}
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	if updated {
+		return ctx.JSON(&domain.CommonResponse{})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Msg:  "无数据被更新",
+		Data: false,
+	})
+}
+
+func (c *tableRelationController) Paginate(ctx *fiber.Ctx) error {
+	pr := new(model.TableRelationRequest)
+	if err := ctx.QueryParser(pr); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	limit, offset, orderBy, err := parsePaginationInfoFromQuery(ctx)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+
+	timeRangeMap := make(map[string]*domain.TimeCondition)
+	//if pr.CreateTimeRange != nil {
+	//	timeRangeMap["create_time"] = &domain.TimeCondition{
+	//		Start: pr.CreateTimeRange.Start,
+	//		End:   pr.CreateTimeRange.End,
+	//	}
+	//}
+
+	total, list, err := service.TableRelationService.PaginateBetweenTimes(pr.TableRelation, limit, offset, orderBy, timeRangeMap)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{Data: &domain.Paginate{
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+		Items:  list,
+	}})
+}
+
+func (c *tableRelationController) Get(ctx *fiber.Ctx) error {
+	instance := new(model.TableRelation)
+	var err error
+	if err = ctx.QueryParser(instance); err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeBodyParse,
+			Msg:  "参数解析失败!",
+		})
+	}
+	instance, err = service.TableRelationService.Get(instance)
+	if err != nil {
+		logger.Error(err)
+		return ctx.JSON(&domain.CommonResponse{
+			Code: constant.ErrorCodeService,
+			Msg:  "服务出现异常",
+		})
+	}
+	return ctx.JSON(&domain.CommonResponse{
+		Data: instance,
+	})
+}
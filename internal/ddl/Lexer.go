@@ -0,0 +1,192 @@
+package ddl
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenIdent  tokenKind = iota // 标识符或关键字
+	tokenQuoted                  // 引号包围的标识符
+	tokenString                  // 字符串字面量
+	tokenNumber                  // 数字
+	tokenSymbol                  // 符号
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// splitStatements 按分号拆分脚本并去除注释，忽略引号及$$内的分号
+func splitStatements(script string) []string {
+	rs := []rune(script)
+	var statements []string
+	sb := new(strings.Builder)
+	flush := func() {
+		if s := strings.TrimSpace(sb.String()); s != "" {
+			statements = append(statements, s)
+		}
+		sb.Reset()
+	}
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-', r == '#':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			sb.WriteRune('\n')
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/') {
+				i++
+			}
+			i++
+			sb.WriteRune(' ')
+		case r == '\'' || r == '"' || r == '`':
+			end := quotedEnd(rs, i)
+			sb.WriteString(string(rs[i:end]))
+			i = end - 1
+		case r == '$':
+			// PostgreSQL的$tag$引用
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			if j < len(rs) && rs[j] == '$' {
+				tag := string(rs[i : j+1])
+				rest := string(rs[j+1:])
+				if k := strings.Index(rest, tag); k >= 0 {
+					end := j + 1 + utf8.RuneCountInString(rest[:k]) + utf8.RuneCountInString(tag)
+					sb.WriteString(string(rs[i:end]))
+					i = end - 1
+					continue
+				}
+			}
+			sb.WriteRune(r)
+		case r == ';':
+			flush()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	flush()
+	return statements
+}
+
+// quotedEnd 返回从start处引号开始的引用内容结束后的位置，支持重复引号及反斜杠转义
+func quotedEnd(rs []rune, start int) int {
+	q := rs[start]
+	i := start + 1
+	for i < len(rs) {
+		if rs[i] == '\\' && q == '\'' {
+			i += 2
+			continue
+		}
+		if rs[i] == q {
+			if i+1 < len(rs) && rs[i+1] == q {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return len(rs)
+}
+
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	q := s[0]
+	body := s[1 : len(s)-1]
+	if q == '\'' {
+		body = strings.ReplaceAll(body, "''", "'")
+		body = strings.NewReplacer(`\'`, "'", `\\`, `\`, `\n`, "\n", `\t`, "\t", `\"`, `"`).Replace(body)
+		return body
+	}
+	return strings.ReplaceAll(body, string([]byte{q, q}), string(q))
+}
+
+func tokenize(statement string) []token {
+	rs := []rune(statement)
+	var tokens []token
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			end := quotedEnd(rs, i)
+			tokens = append(tokens, token{kind: tokenString, text: unquote(string(rs[i:end]))})
+			i = end
+		case r == '"' || r == '`':
+			end := quotedEnd(rs, i)
+			tokens = append(tokens, token{kind: tokenQuoted, text: unquote(string(rs[i:end]))})
+			i = end
+		case r == '[':
+			end := i + 1
+			for end < len(rs) && rs[end] != ']' {
+				end++
+			}
+			if end < len(rs) && end > i+1 && isIdentStart(rs[i+1]) {
+				tokens = append(tokens, token{kind: tokenQuoted, text: string(rs[i+1 : end])})
+				i = end + 1
+			} else {
+				// 数组类型标记等，按符号处理
+				tokens = append(tokens, token{kind: tokenSymbol, text: "["})
+				i++
+			}
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			end := i
+			for end < len(rs) && (unicode.IsDigit(rs[end]) || rs[end] == '.' || rs[end] == 'e' || rs[end] == 'E') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(rs[i:end])})
+			i = end
+		case isIdentStart(r):
+			end := i
+			for end < len(rs) && (isIdentStart(rs[end]) || unicode.IsDigit(rs[end]) || rs[end] == '$') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(rs[i:end])})
+			i = end
+		case r == ':' && i+1 < len(rs) && rs[i+1] == ':':
+			tokens = append(tokens, token{kind: tokenSymbol, text: "::"})
+			i += 2
+		case r == '$':
+			// $tag$内容$tag$ 作为字符串处理
+			j := i + 1
+			for j < len(rs) && rs[j] != '$' {
+				j++
+			}
+			k := -1
+			var tag, rest string
+			if j < len(rs) {
+				tag = string(rs[i : j+1])
+				rest = string(rs[j+1:])
+				k = strings.Index(rest, tag)
+			}
+			if k < 0 {
+				tokens = append(tokens, token{kind: tokenSymbol, text: "$"})
+				i++
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenString, text: rest[:k]})
+			i = j + 1 + utf8.RuneCountInString(rest[:k]) + utf8.RuneCountInString(tag)
+		default:
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r)})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
@@ -0,0 +1,189 @@
+package ddl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		statements []string
+	}{
+		{
+			name:       "多条语句",
+			script:     "create table a (id int);\ncreate table b (id int);",
+			statements: []string{"create table a (id int)", "create table b (id int)"},
+		},
+		{
+			name:       "末尾没有分号",
+			script:     "create table a (id int);\ncreate table b (id int)",
+			statements: []string{"create table a (id int)", "create table b (id int)"},
+		},
+		{
+			name:       "空语句",
+			script:     ";;\n  ;create table a (id int);;",
+			statements: []string{"create table a (id int)"},
+		},
+		{
+			name:       "单引号内的分号",
+			script:     "insert into a values ('x;y');select 1",
+			statements: []string{"insert into a values ('x;y')", "select 1"},
+		},
+		{
+			name:       "重复引号转义",
+			script:     "select 'it''s;';select 2",
+			statements: []string{"select 'it''s;'", "select 2"},
+		},
+		{
+			name:       "反斜杠转义",
+			script:     `select 'a\';b';select 2`,
+			statements: []string{`select 'a\';b'`, "select 2"},
+		},
+		{
+			name:       "双引号及反引号内的分号",
+			script:     "create table \"a;b\" (`c;d` int);select 1",
+			statements: []string{"create table \"a;b\" (`c;d` int)", "select 1"},
+		},
+		{
+			name:       "双横线注释",
+			script:     "-- 建表;\ncreate table a (\n  id int -- 主键;\n);",
+			statements: []string{"create table a (\n  id int \n)"},
+		},
+		{
+			name:       "井号注释",
+			script:     "# 建表;\ncreate table a (id int);",
+			statements: []string{"create table a (id int)"},
+		},
+		{
+			name:       "块注释",
+			script:     "/* 建表; */create table a (id /* 主键; */int);",
+			statements: []string{"create table a (id  int)"},
+		},
+		{
+			name:       "未结束的块注释",
+			script:     "create table a (id int);/* 未结束;",
+			statements: []string{"create table a (id int)"},
+		},
+		{
+			name:       "引号内的注释符号",
+			script:     "select '-- a', '/* b */', '# c';select 2",
+			statements: []string{"select '-- a', '/* b */', '# c'", "select 2"},
+		},
+		{
+			name:       "美元符号引用",
+			script:     "create function f() returns int as $$ select 1; $$ language sql;select 2",
+			statements: []string{"create function f() returns int as $$ select 1; $$ language sql", "select 2"},
+		},
+		{
+			name:       "带标签的美元符号引用",
+			script:     "do $body$ begin perform 1; end $body$;select 2",
+			statements: []string{"do $body$ begin perform 1; end $body$", "select 2"},
+		},
+		{
+			name:       "美元符号引用内的其他标签及多字节字符",
+			script:     "do $a$ 中文;$b$;$b$ $a$;select 2",
+			statements: []string{"do $a$ 中文;$b$;$b$ $a$", "select 2"},
+		},
+		{
+			name:       "未闭合的美元符号",
+			script:     "select $1;select 2",
+			statements: []string{"select $1", "select 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := splitStatements(tt.script)
+			if statements == nil {
+				statements = []string{}
+			}
+			if !reflect.DeepEqual(statements, tt.statements) {
+				t.Errorf("statements = %q, want %q", statements, tt.statements)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		tokens []string
+	}{
+		{
+			name:   "标识符、数字及符号",
+			text:   "id decimal(10, 2)",
+			tokens: []string{"ident:id", "ident:decimal", "symbol:(", "number:10", "symbol:,", "number:2", "symbol:)"},
+		},
+		{
+			name:   "引号包围的标识符",
+			text:   "`user name` \"Order\" [key]",
+			tokens: []string{"quoted:user name", "quoted:Order", "quoted:key"},
+		},
+		{
+			name:   "引号包围的标识符内的重复引号",
+			text:   "\"a\"\"b\" `c``d`",
+			tokens: []string{"quoted:a\"b", "quoted:c`d"},
+		},
+		{
+			name:   "字符串转义",
+			text:   `'it''s' 'a\'b' 'c\\d' 'e\nf'`,
+			tokens: []string{"string:it's", "string:a'b", `string:c\d`, "string:e\nf"},
+		},
+		{
+			name:   "数组类型标记",
+			text:   "tags text[]",
+			tokens: []string{"ident:tags", "ident:text", "symbol:[", "symbol:]"},
+		},
+		{
+			name:   "小数及科学计数法",
+			text:   "1.5 .5 1e10",
+			tokens: []string{"number:1.5", "number:.5", "number:1e10"},
+		},
+		{
+			name:   "类型转换",
+			text:   "'a'::character varying",
+			tokens: []string{"string:a", "symbol:::", "ident:character", "ident:varying"},
+		},
+		{
+			name:   "美元符号引用作为字符串",
+			text:   "$$it's$$ $tag$a$$b$tag$",
+			tokens: []string{"string:it's", "string:a$$b"},
+		},
+		{
+			name:   "美元符号引用内的多字节字符",
+			text:   "$$中文$$ x",
+			tokens: []string{"string:中文", "ident:x"},
+		},
+		{
+			name:   "未闭合的美元符号",
+			text:   "$1",
+			tokens: []string{"symbol:$", "number:1"},
+		},
+		{
+			name:   "标识符中的美元符号",
+			text:   "a$b",
+			tokens: []string{"ident:a$b"},
+		},
+	}
+	kinds := map[tokenKind]string{
+		tokenIdent:  "ident",
+		tokenQuoted: "quoted",
+		tokenString: "string",
+		tokenNumber: "number",
+		tokenSymbol: "symbol",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := make([]string, 0)
+			for _, token := range tokenize(tt.text) {
+				tokens = append(tokens, fmt.Sprintf("%s:%s", kinds[token.kind], token.text))
+			}
+			if !reflect.DeepEqual(tokens, tt.tokens) {
+				t.Errorf("tokens = %q, want %q", tokens, tt.tokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,701 @@
+package ddl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	StatementCreateTable = "createTable"
+	StatementComment     = "comment"
+	StatementUniqueIndex = "uniqueIndex"
+	StatementIgnored     = "ignored"
+)
+
+// Column 解析出的字段定义
+type Column struct {
+	Name          string
+	Type          string // 规范化后的类型名，如VARCHAR、DECIMAL、TIMESTAMPTZ
+	Length        int
+	Length2       int
+	EnumValues    []string
+	NotNull       bool
+	HasDefault    bool
+	Default       string
+	DefaultIsExpr bool // 默认值是函数或表达式，而非字面量
+	Comment       string
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+// Table 解析出的表定义
+type Table struct {
+	Name       string
+	Comment    string
+	Columns    []*Column
+	PrimaryKey []string
+	UniqueKeys [][]string
+}
+
+// Column 按名称查找字段，不区分大小写
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Statement 单条语句的解析报告
+type Statement struct {
+	Index    int      // 语句序号，从1开始
+	Text     string   // 语句开头部分
+	Kind     string   // 语句类型
+	Table    string   // 语句作用的表
+	Messages []string // 无法处理的内容说明
+}
+
+func (s *Statement) addMessage(format string, args ...interface{}) {
+	s.Messages = append(s.Messages, fmt.Sprintf(format, args...))
+}
+
+// Script 解析结果
+type Script struct {
+	Tables     []*Table
+	Statements []*Statement
+}
+
+// Table 按名称查找表，不区分大小写
+func (s *Script) Table(name string) *Table {
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// Parse 解析MySQL/PostgreSQL的建表脚本，支持CREATE TABLE、COMMENT ON、CREATE UNIQUE INDEX及ALTER TABLE ADD UNIQUE
+func Parse(script string) *Script {
+	result := new(Script)
+	for i, text := range splitStatements(script) {
+		stmt := &Statement{Index: i + 1, Text: abbreviate(text, 80)}
+		p := &parser{tokens: tokenize(text), stmt: stmt, script: result}
+		p.parseStatement()
+		result.Statements = append(result.Statements, stmt)
+	}
+	return result
+}
+
+func abbreviate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	return string(rs[:n]) + "..."
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	stmt   *Statement
+	script *Script
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.eof() {
+		return token{kind: tokenSymbol}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// isKeyword 判断当前位置起是否为给定的关键字序列
+func (p *parser) isKeyword(keywords ...string) bool {
+	for i, kw := range keywords {
+		if p.pos+i >= len(p.tokens) {
+			return false
+		}
+		t := p.tokens[p.pos+i]
+		if t.kind != tokenIdent || !strings.EqualFold(t.text, kw) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) acceptKeyword(keywords ...string) bool {
+	if p.isKeyword(keywords...) {
+		p.pos += len(keywords)
+		return true
+	}
+	return false
+}
+
+func (p *parser) isSymbol(s string) bool {
+	t := p.peek()
+	return !p.eof() && t.kind == tokenSymbol && t.text == s
+}
+
+func (p *parser) acceptSymbol(s string) bool {
+	if p.isSymbol(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) isName() bool {
+	t := p.peek()
+	return !p.eof() && (t.kind == tokenIdent || t.kind == tokenQuoted)
+}
+
+// parseQualifiedName 解析以.分隔的名称，返回各部分
+func (p *parser) parseQualifiedName() []string {
+	var parts []string
+	for p.isName() {
+		parts = append(parts, p.next().text)
+		if !p.acceptSymbol(".") {
+			break
+		}
+	}
+	return parts
+}
+
+func (p *parser) parseName() string {
+	parts := p.parseQualifiedName()
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
+
+// skipParens 跳过当前位置的括号及其内容
+func (p *parser) skipParens() {
+	if !p.acceptSymbol("(") {
+		return
+	}
+	depth := 1
+	for !p.eof() && depth > 0 {
+		t := p.next()
+		if t.kind == tokenSymbol {
+			if t.text == "(" {
+				depth++
+			} else if t.text == ")" {
+				depth--
+			}
+		}
+	}
+}
+
+// skipElement 跳到当前定义项结束处(同层的,或))，不消费结束符
+func (p *parser) skipElement() {
+	for !p.eof() {
+		if p.isSymbol(",") || p.isSymbol(")") {
+			return
+		}
+		if p.isSymbol("(") {
+			p.skipParens()
+			continue
+		}
+		p.next()
+	}
+}
+
+// parseNameList 解析(a, b(10) desc, c)形式的字段列表
+func (p *parser) parseNameList() []string {
+	var names []string
+	if !p.acceptSymbol("(") {
+		return nil
+	}
+	for !p.eof() && !p.acceptSymbol(")") {
+		if p.isName() {
+			names = append(names, p.next().text)
+		} else if p.isSymbol("(") {
+			p.skipParens()
+			continue
+		} else {
+			p.next()
+			continue
+		}
+		if p.isSymbol("(") {
+			p.skipParens()
+		}
+		p.acceptKeyword("ASC")
+		p.acceptKeyword("DESC")
+		p.acceptSymbol(",")
+	}
+	return names
+}
+
+func (p *parser) parseStatement() {
+	switch {
+	case p.acceptKeyword("CREATE"):
+		p.acceptKeyword("OR", "REPLACE")
+		p.acceptKeyword("TEMPORARY")
+		p.acceptKeyword("TEMP")
+		p.acceptKeyword("UNLOGGED")
+		if p.acceptKeyword("TABLE") {
+			p.parseCreateTable()
+			return
+		}
+		if p.acceptKeyword("UNIQUE") {
+			p.parseUniqueIndex()
+			return
+		}
+		if p.isKeyword("INDEX") {
+			p.stmt.Kind = StatementIgnored
+			p.stmt.addMessage("普通索引未导入")
+			return
+		}
+	case p.acceptKeyword("COMMENT", "ON"):
+		p.parseCommentOn()
+		return
+	case p.acceptKeyword("ALTER", "TABLE"):
+		p.parseAlterTable()
+		return
+	}
+	p.stmt.Kind = StatementIgnored
+	p.stmt.addMessage("不支持的语句类型")
+}
+
+func (p *parser) parseCreateTable() {
+	p.stmt.Kind = StatementCreateTable
+	p.acceptKeyword("IF", "NOT", "EXISTS")
+	table := &Table{Name: p.parseName()}
+	p.stmt.Table = table.Name
+	if table.Name == "" || !p.acceptSymbol("(") {
+		p.stmt.Kind = StatementIgnored
+		p.stmt.addMessage("无法解析表定义")
+		return
+	}
+	for !p.eof() {
+		p.parseTableElement(table)
+		if p.acceptSymbol(",") {
+			continue
+		}
+		if p.acceptSymbol(")") {
+			break
+		}
+		p.stmt.addMessage("表定义中存在无法识别的内容: %s", p.peek().text)
+		p.skipElement()
+		if !p.acceptSymbol(",") && !p.acceptSymbol(")") {
+			break
+		}
+	}
+	// 表选项，仅识别注释
+	for !p.eof() {
+		if p.acceptKeyword("COMMENT") {
+			p.acceptSymbol("=")
+			if t := p.peek(); t.kind == tokenString {
+				table.Comment = p.next().text
+			}
+			continue
+		}
+		p.next()
+	}
+	for _, c := range table.Columns {
+		if c.PrimaryKey {
+			table.PrimaryKey = append(table.PrimaryKey, c.Name)
+		}
+	}
+	if existing := p.script.Table(table.Name); existing != nil {
+		p.stmt.addMessage("表%s重复定义，以后出现的为准", table.Name)
+		*existing = *table
+		return
+	}
+	p.script.Tables = append(p.script.Tables, table)
+}
+
+func (p *parser) parseTableElement(table *Table) {
+	if p.acceptKeyword("CONSTRAINT") {
+		p.parseName()
+	}
+	switch {
+	case p.acceptKeyword("PRIMARY", "KEY"):
+		for _, name := range p.parseNameList() {
+			if c := table.Column(name); c != nil {
+				c.PrimaryKey = true
+			}
+		}
+	case p.acceptKeyword("UNIQUE"):
+		if !p.acceptKeyword("KEY") {
+			p.acceptKeyword("INDEX")
+		}
+		if p.isName() && !p.isKeyword("USING") {
+			p.parseName()
+		}
+		if p.acceptKeyword("USING") {
+			p.next()
+		}
+		if cols := p.parseNameList(); len(cols) > 0 {
+			table.UniqueKeys = append(table.UniqueKeys, cols)
+		}
+	case p.isKeyword("KEY"), p.isKeyword("INDEX"), p.isKeyword("FULLTEXT"), p.isKeyword("SPATIAL"):
+		p.stmt.addMessage("普通索引未导入")
+	case p.isKeyword("FOREIGN"):
+		p.stmt.addMessage("外键约束未导入")
+	case p.isKeyword("CHECK"), p.isKeyword("EXCLUDE"):
+		p.stmt.addMessage("%s约束未导入", strings.ToUpper(p.peek().text))
+	case p.isName():
+		table.Columns = append(table.Columns, p.parseColumn(table))
+		return
+	}
+	p.skipElement()
+}
+
+// 类型名可由多个单词组成，如 character varying、double precision、timestamp with time zone
+var typeContinuations = map[string]bool{
+	"VARYING": true, "PRECISION": true, "UNSIGNED": true, "SIGNED": true, "ZEROFILL": true,
+	"WITH": true, "WITHOUT": true, "TIME": true, "ZONE": true, "LOCAL": true,
+}
+
+func (p *parser) parseColumn(table *Table) *Column {
+	tableName := table.Name
+	column := &Column{Name: p.next().text}
+
+	// 类型
+	var words []string
+	if p.isName() {
+		words = append(words, strings.ToUpper(p.next().text))
+	}
+	for {
+		if p.isSymbol("(") {
+			p.parseTypeArgs(column)
+			continue
+		}
+		if p.isSymbol("[") {
+			p.next()
+			p.acceptSymbol("]")
+			p.stmt.addMessage("%s.%s: 数组类型按普通类型导入", tableName, column.Name)
+			continue
+		}
+		t := p.peek()
+		if t.kind == tokenIdent && typeContinuations[strings.ToUpper(t.text)] {
+			words = append(words, strings.ToUpper(p.next().text))
+			continue
+		}
+		break
+	}
+	column.Type = normalizeType(words)
+	if column.Type == "SERIAL" || column.Type == "BIGSERIAL" || column.Type == "SMALLSERIAL" {
+		column.AutoIncrement = true
+	}
+
+	// 字段约束
+	for !p.eof() && !p.isSymbol(",") && !p.isSymbol(")") {
+		switch {
+		case p.acceptKeyword("NOT", "NULL"):
+			column.NotNull = true
+		case p.acceptKeyword("NULL"):
+		case p.acceptKeyword("DEFAULT"):
+			p.parseDefault(column)
+		case p.acceptKeyword("COMMENT"):
+			if t := p.peek(); t.kind == tokenString {
+				column.Comment = p.next().text
+			}
+		case p.acceptKeyword("PRIMARY", "KEY"):
+			column.PrimaryKey = true
+			column.NotNull = true
+		case p.acceptKeyword("UNIQUE"):
+			p.acceptKeyword("KEY")
+			table.UniqueKeys = append(table.UniqueKeys, []string{column.Name})
+		case p.acceptKeyword("AUTO_INCREMENT"), p.acceptKeyword("AUTOINCREMENT"):
+			column.AutoIncrement = true
+		case p.acceptKeyword("CONSTRAINT"):
+			p.parseName()
+		case p.acceptKeyword("REFERENCES"):
+			p.parseName()
+			p.parseNameList()
+			for p.acceptKeyword("ON") {
+				p.next() // DELETE/UPDATE
+				if !p.acceptKeyword("SET", "NULL") && !p.acceptKeyword("SET", "DEFAULT") && !p.acceptKeyword("NO", "ACTION") {
+					p.next()
+				}
+			}
+			p.stmt.addMessage("%s.%s: 外键引用未导入", tableName, column.Name)
+		case p.acceptKeyword("CHECK"):
+			p.skipParens()
+			p.stmt.addMessage("%s.%s: CHECK约束未导入", tableName, column.Name)
+		case p.acceptKeyword("COLLATE"), p.acceptKeyword("CHARACTER", "SET"), p.acceptKeyword("CHARSET"):
+			p.parseName()
+		case p.acceptKeyword("ON", "UPDATE"):
+			p.parseExpression()
+			p.stmt.addMessage("%s.%s: ON UPDATE未导入", tableName, column.Name)
+		case p.acceptKeyword("GENERATED"):
+			p.parseGenerated(tableName, column)
+		default:
+			t := p.next()
+			if t.kind == tokenSymbol && t.text == "(" {
+				p.pos--
+				p.skipParens()
+			}
+			p.stmt.addMessage("%s.%s: 无法识别的字段属性%s", tableName, column.Name, t.text)
+		}
+	}
+	return column
+}
+
+func (p *parser) parseTypeArgs(column *Column) {
+	p.acceptSymbol("(")
+	var args []int
+	for !p.eof() && !p.acceptSymbol(")") {
+		t := p.next()
+		switch t.kind {
+		case tokenNumber:
+			if n, err := strconv.Atoi(t.text); err == nil {
+				args = append(args, n)
+			}
+		case tokenString:
+			column.EnumValues = append(column.EnumValues, t.text)
+		}
+	}
+	if len(args) > 0 {
+		column.Length = args[0]
+	}
+	if len(args) > 1 {
+		column.Length2 = args[1]
+	}
+}
+
+func normalizeType(words []string) string {
+	var base []string
+	for _, w := range words {
+		if w == "UNSIGNED" || w == "SIGNED" || w == "ZEROFILL" {
+			continue
+		}
+		base = append(base, w)
+	}
+	joined := strings.Join(base, " ")
+	switch {
+	case joined == "":
+		return ""
+	case strings.HasPrefix(joined, "CHARACTER VARYING"):
+		return "VARCHAR"
+	case joined == "CHARACTER":
+		return "CHAR"
+	case joined == "DOUBLE PRECISION":
+		return "DOUBLE"
+	case strings.HasPrefix(joined, "TIMESTAMP WITH TIME ZONE"):
+		return "TIMESTAMPTZ"
+	case strings.HasPrefix(joined, "TIME WITH TIME ZONE"):
+		return "TIMETZ"
+	}
+	return base[0]
+}
+
+// parseDefault 解析默认值，字面量记录为值，函数或表达式仅记录原文
+func (p *parser) parseDefault(column *Column) {
+	column.HasDefault = true
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		column.Default = p.next().text
+	case t.kind == tokenNumber:
+		column.Default = p.next().text
+	case t.kind == tokenSymbol && (t.text == "-" || t.text == "+"):
+		p.next()
+		n := p.next()
+		column.Default = t.text + n.text
+		if n.kind != tokenNumber {
+			column.DefaultIsExpr = true
+		}
+	case p.acceptKeyword("NULL"):
+		column.HasDefault = false
+	case p.isKeyword("TRUE"), p.isKeyword("FALSE"):
+		column.Default = strings.ToLower(p.next().text)
+	default:
+		column.Default = p.parseExpression()
+		column.DefaultIsExpr = true
+	}
+	// PostgreSQL类型转换，如 'a'::character varying
+	for p.acceptSymbol("::") {
+		p.parseName()
+		for {
+			t := p.peek()
+			if t.kind == tokenIdent && typeContinuations[strings.ToUpper(t.text)] {
+				p.next()
+				continue
+			}
+			break
+		}
+		if p.isSymbol("(") {
+			p.skipParens()
+		}
+	}
+}
+
+// parseExpression 解析函数调用、关键字或括号表达式，返回原文
+func (p *parser) parseExpression() string {
+	start := p.pos
+	if p.isSymbol("(") {
+		p.skipParens()
+	} else {
+		p.next()
+		if p.isSymbol("(") {
+			p.skipParens()
+		}
+	}
+	var parts []string
+	for _, t := range p.tokens[start:p.pos] {
+		parts = append(parts, t.text)
+	}
+	return strings.Join(parts, "")
+}
+
+func (p *parser) parseGenerated(tableName string, column *Column) {
+	for !p.eof() && !p.isSymbol(",") && !p.isSymbol(")") {
+		if p.isKeyword("NOT") || p.isKeyword("NULL") || p.isKeyword("COMMENT") || p.isKeyword("PRIMARY") || p.isKeyword("UNIQUE") {
+			return
+		}
+		if p.acceptKeyword("IDENTITY") {
+			column.AutoIncrement = true
+			continue
+		}
+		if p.isSymbol("(") {
+			p.skipParens()
+			continue
+		}
+		if p.acceptKeyword("STORED") || p.acceptKeyword("VIRTUAL") {
+			p.stmt.addMessage("%s.%s: 计算列按普通字段导入", tableName, column.Name)
+			continue
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseUniqueIndex() {
+	p.stmt.Kind = StatementUniqueIndex
+	if !p.acceptKeyword("INDEX") {
+		p.stmt.Kind = StatementIgnored
+		p.stmt.addMessage("不支持的语句类型")
+		return
+	}
+	p.acceptKeyword("CONCURRENTLY")
+	p.acceptKeyword("IF", "NOT", "EXISTS")
+	if !p.isKeyword("ON") {
+		p.parseName()
+	}
+	if !p.acceptKeyword("ON") {
+		p.stmt.Kind = StatementIgnored
+		p.stmt.addMessage("无法解析唯一索引定义")
+		return
+	}
+	p.acceptKeyword("ONLY")
+	tableName := p.parseName()
+	p.stmt.Table = tableName
+	if p.acceptKeyword("USING") {
+		p.next()
+	}
+	cols := p.parseNameList()
+	p.addUnique(tableName, cols)
+	if p.acceptKeyword("WHERE") {
+		p.stmt.addMessage("部分唯一索引的条件未导入")
+	}
+}
+
+func (p *parser) parseCommentOn() {
+	p.stmt.Kind = StatementComment
+	var target string
+	switch {
+	case p.acceptKeyword("TABLE"):
+		target = "TABLE"
+	case p.acceptKeyword("COLUMN"):
+		target = "COLUMN"
+	default:
+		p.stmt.Kind = StatementIgnored
+		p.stmt.addMessage("仅支持表及字段的注释")
+		return
+	}
+	parts := p.parseQualifiedName()
+	if !p.acceptKeyword("IS") || len(parts) == 0 {
+		p.stmt.Kind = StatementIgnored
+		p.stmt.addMessage("无法解析注释语句")
+		return
+	}
+	comment := ""
+	if t := p.peek(); t.kind == tokenString {
+		comment = t.text
+	}
+	if target == "TABLE" {
+		tableName := parts[len(parts)-1]
+		p.stmt.Table = tableName
+		table := p.script.Table(tableName)
+		if table == nil {
+			p.stmt.addMessage("表%s未在脚本中定义", tableName)
+			return
+		}
+		table.Comment = comment
+		return
+	}
+	if len(parts) < 2 {
+		p.stmt.addMessage("字段注释缺少表名")
+		return
+	}
+	tableName, columnName := parts[len(parts)-2], parts[len(parts)-1]
+	p.stmt.Table = tableName
+	table := p.script.Table(tableName)
+	if table == nil {
+		p.stmt.addMessage("表%s未在脚本中定义", tableName)
+		return
+	}
+	column := table.Column(columnName)
+	if column == nil {
+		p.stmt.addMessage("字段%s.%s未在脚本中定义", tableName, columnName)
+		return
+	}
+	column.Comment = comment
+}
+
+func (p *parser) parseAlterTable() {
+	p.acceptKeyword("ONLY")
+	p.acceptKeyword("IF", "EXISTS")
+	tableName := p.parseName()
+	p.stmt.Table = tableName
+	p.stmt.Kind = StatementUniqueIndex
+	if !p.acceptKeyword("ADD") {
+		p.stmt.Kind = StatementIgnored
+		p.stmt.addMessage("仅支持ALTER TABLE ADD UNIQUE")
+		return
+	}
+	if p.acceptKeyword("CONSTRAINT") {
+		p.parseName()
+	}
+	if p.acceptKeyword("UNIQUE") {
+		if !p.acceptKeyword("KEY") {
+			p.acceptKeyword("INDEX")
+		}
+		if p.isName() {
+			p.parseName()
+		}
+		p.addUnique(tableName, p.parseNameList())
+		return
+	}
+	p.stmt.Kind = StatementIgnored
+	p.stmt.addMessage("仅支持ALTER TABLE ADD UNIQUE")
+}
+
+func (p *parser) addUnique(tableName string, cols []string) {
+	table := p.script.Table(tableName)
+	if table == nil {
+		p.stmt.addMessage("表%s未在脚本中定义", tableName)
+		return
+	}
+	if len(cols) == 0 {
+		p.stmt.addMessage("唯一索引未包含字段")
+		return
+	}
+	table.UniqueKeys = append(table.UniqueKeys, cols)
+}
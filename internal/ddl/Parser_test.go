@@ -0,0 +1,177 @@
+package ddl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		tables     []*Table
+		statements []string
+	}{
+		{
+			name: "MySQL建表",
+			script: "CREATE TABLE IF NOT EXISTS `user` (\n" +
+				"  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键',\n" +
+				"  `name` varchar(50) NOT NULL DEFAULT '' COMMENT '用户名',\n" +
+				"  `price` decimal(10,2) DEFAULT '0.00',\n" +
+				"  `status` enum('on','off') DEFAULT 'on',\n" +
+				"  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  UNIQUE KEY `uk_name` (`name`),\n" +
+				"  KEY `idx_status` (`status`)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户';",
+			tables: []*Table{{
+				Name:    "user",
+				Comment: "用户",
+				Columns: []*Column{
+					{Name: "id", Type: "BIGINT", Length: 20, NotNull: true, AutoIncrement: true, Comment: "主键", PrimaryKey: true},
+					{Name: "name", Type: "VARCHAR", Length: 50, NotNull: true, HasDefault: true, Comment: "用户名"},
+					{Name: "price", Type: "DECIMAL", Length: 10, Length2: 2, HasDefault: true, Default: "0.00"},
+					{Name: "status", Type: "ENUM", EnumValues: []string{"on", "off"}, HasDefault: true, Default: "on"},
+					{Name: "created_at", Type: "DATETIME", HasDefault: true, Default: "CURRENT_TIMESTAMP", DefaultIsExpr: true},
+				},
+				PrimaryKey: []string{"id"},
+				UniqueKeys: [][]string{{"name"}},
+			}},
+			statements: []string{"createTable user [普通索引未导入]"},
+		},
+		{
+			name: "PostgreSQL建表及注释、唯一索引",
+			script: "CREATE TABLE public.orders (\n" +
+				"  id serial PRIMARY KEY,\n" +
+				"  code character varying(32) NOT NULL DEFAULT 'a'::character varying,\n" +
+				"  paid_at timestamp with time zone DEFAULT now(),\n" +
+				"  amount numeric(12,2) DEFAULT -1,\n" +
+				"  remark text DEFAULT NULL,\n" +
+				"  enabled boolean DEFAULT TRUE\n" +
+				");\n" +
+				"COMMENT ON TABLE public.orders IS '订单';\n" +
+				"COMMENT ON COLUMN public.orders.code IS '编号';\n" +
+				"CREATE UNIQUE INDEX uk_orders_code ON public.orders USING btree (code);\n" +
+				"ALTER TABLE ONLY public.orders ADD CONSTRAINT uk_paid UNIQUE (paid_at, amount);",
+			tables: []*Table{{
+				Name:    "orders",
+				Comment: "订单",
+				Columns: []*Column{
+					{Name: "id", Type: "SERIAL", NotNull: true, PrimaryKey: true, AutoIncrement: true},
+					{Name: "code", Type: "VARCHAR", Length: 32, NotNull: true, HasDefault: true, Default: "a", Comment: "编号"},
+					{Name: "paid_at", Type: "TIMESTAMPTZ", HasDefault: true, Default: "now()", DefaultIsExpr: true},
+					{Name: "amount", Type: "NUMERIC", Length: 12, Length2: 2, HasDefault: true, Default: "-1"},
+					{Name: "remark", Type: "TEXT"},
+					{Name: "enabled", Type: "BOOLEAN", HasDefault: true, Default: "true"},
+				},
+				PrimaryKey: []string{"id"},
+				UniqueKeys: [][]string{{"code"}, {"paid_at", "amount"}},
+			}},
+			statements: []string{
+				"createTable orders []",
+				"comment orders []",
+				"comment orders []",
+				"uniqueIndex orders []",
+				"uniqueIndex orders []",
+			},
+		},
+		{
+			name: "注释及字符串中的分号",
+			script: "-- 建表;\n" +
+				"/* 旧表;\n已删除 */\n" +
+				"create table \"a;b\" (\n" +
+				"  c varchar(10) default 'x;y' comment 'it''s; ok' # 字段;\n" +
+				");",
+			tables: []*Table{{
+				Name: "a;b",
+				Columns: []*Column{
+					{Name: "c", Type: "VARCHAR", Length: 10, HasDefault: true, Default: "x;y", Comment: "it's; ok"},
+				},
+			}},
+			statements: []string{"createTable a;b []"},
+		},
+		{
+			name: "函数体内的分号",
+			script: "create table a (id int);\n" +
+				"create function f() returns trigger as $body$ begin new.id := 1; return new; end; $body$ language plpgsql;\n" +
+				"create table b (id int);",
+			tables: []*Table{
+				{Name: "a", Columns: []*Column{{Name: "id", Type: "INT"}}},
+				{Name: "b", Columns: []*Column{{Name: "id", Type: "INT"}}},
+			},
+			statements: []string{
+				"createTable a []",
+				"ignored  [不支持的语句类型]",
+				"createTable b []",
+			},
+		},
+		{
+			name: "外键及检查约束",
+			script: "create table b (\n" +
+				"  id int,\n" +
+				"  a_id int references a(id) on delete cascade,\n" +
+				"  constraint fk foreign key (a_id) references a(id),\n" +
+				"  check (id > 0)\n" +
+				")",
+			tables: []*Table{{
+				Name: "b",
+				Columns: []*Column{
+					{Name: "id", Type: "INT"},
+					{Name: "a_id", Type: "INT"},
+				},
+			}},
+			statements: []string{"createTable b [b.a_id: 外键引用未导入 外键约束未导入 CHECK约束未导入]"},
+		},
+		{
+			name:   "重复定义的表以后出现的为准",
+			script: "create table a (x int);create table A (y int);",
+			tables: []*Table{{Name: "A", Columns: []*Column{{Name: "y", Type: "INT"}}}},
+			statements: []string{
+				"createTable a []",
+				"createTable A [表A重复定义，以后出现的为准]",
+			},
+		},
+		{
+			name: "不支持的语句",
+			script: "insert into a values (1);\n" +
+				"create index idx on a (b);\n" +
+				"comment on table missing is 'x';\n" +
+				"create unique index uk on missing (b);\n" +
+				"alter table a drop column b;",
+			tables: []*Table{},
+			statements: []string{
+				"ignored  [不支持的语句类型]",
+				"ignored  [普通索引未导入]",
+				"comment missing [表missing未在脚本中定义]",
+				"uniqueIndex missing [表missing未在脚本中定义]",
+				"ignored a [仅支持ALTER TABLE ADD UNIQUE]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := Parse(tt.script)
+			tables := script.Tables
+			if tables == nil {
+				tables = []*Table{}
+			}
+			if !reflect.DeepEqual(tables, tt.tables) {
+				for _, table := range tables {
+					t.Logf("table %+v", *table)
+					for _, column := range table.Columns {
+						t.Logf("  column %+v", *column)
+					}
+				}
+				t.Errorf("tables mismatch")
+			}
+			statements := make([]string, 0)
+			for _, stmt := range script.Statements {
+				statements = append(statements, fmt.Sprintf("%s %s %v", stmt.Kind, stmt.Table, stmt.Messages))
+			}
+			if !reflect.DeepEqual(statements, tt.statements) {
+				t.Errorf("statements = %q, want %q", statements, tt.statements)
+			}
+		})
+	}
+}
@@ -14,7 +14,10 @@ import (
 
 func InitData() {
 	syncDB()
+	migrateData()
 	checkInitialAuthorizationData()
+	checkBuiltinTemplates()
+	loadApplicationRuntimes()
 }
 
 func checkInitialAuthorizationData() {
@@ -62,7 +65,32 @@ func checkInitialAuthorizationData() {
 	authorization.SetSuperAdmin(role.Id)
 }
 
+// migrateData 迁移旧版本的数据
+func migrateData() {
+	if err := service.ColumnConfigService.MigrateColumnTypes(); err != nil {
+		logger.Error(err)
+	}
+}
+
+// loadApplicationRuntimes 重新注册运行中应用的运行时接口
+func loadApplicationRuntimes() {
+	if err := service.ApplicationRuntimeService.LoadAll(); err != nil {
+		logger.Error(err)
+	}
+}
+
 func syncDB() {
-	database.DB.Sync2(domain.SyncDomains...)
-	database.DB.Sync2(model.SyncModels...)
+	if err := database.DB.Sync2(domain.SyncDomains...); err != nil {
+		logger.Error(err)
+	}
+	// 版本号唯一索引要求已有数据的版本号不重复，需在同步表结构之前补齐
+	if err := service.ApplicationSourceService.MigrateVersions(); err != nil {
+		logger.Error(err)
+	}
+	if err := service.CodeTemplateService.MigrateVersions(); err != nil {
+		logger.Error(err)
+	}
+	if err := database.DB.Sync2(model.SyncModels...); err != nil {
+		logger.Error(err)
+	}
 }
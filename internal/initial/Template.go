@@ -0,0 +1,185 @@
+package initial
+
+import (
+	"github.com/yockii/qscore/pkg/logger"
+	"gopkg.in/yaml.v3"
+
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/service"
+)
+
+// 内置应用模板，内容为应用定义清单(yaml)
+var builtinTemplates = []struct {
+	name     string
+	desc     string
+	manifest string
+}{
+	{
+		name: "基础内容管理",
+		desc: "栏目、文章及标签，适用于新闻、博客等内容发布类应用",
+		manifest: `
+manifestVersion: 1
+application:
+  appName: cms
+  package: cms
+tables:
+  - tableName: category
+    tableComment: 栏目
+    recordType: 3
+    columns:
+      - {columnName: category_name, displayName: 栏目名称, columnType: 1, columnLength: 50, zeroValue: "!NIL", uniqueCheck: 1}
+      - {columnName: parent_id, displayName: 上级栏目, columnType: 1, columnLength: 50}
+      - {columnName: sort_no, displayName: 排序, columnType: 2, zeroValue: "0"}
+      - {columnName: enabled, displayName: 是否启用, columnType: 5, zeroValue: "true"}
+  - tableName: article
+    tableComment: 文章
+    recordType: 7
+    columns:
+      - {columnName: category_id, displayName: 所属栏目, columnType: 1, columnLength: 50, zeroValue: "!NIL"}
+      - {columnName: title, displayName: 标题, columnType: 1, columnLength: 200, zeroValue: "!NIL", stringSearch: 2}
+      - {columnName: summary, displayName: 摘要, columnType: 1, columnLength: 500, displayType: 11}
+      - {columnName: content, displayName: 正文, columnType: 1, stringType: 2, displayType: 11}
+      - {columnName: cover, displayName: 封面, columnType: 12}
+      - {columnName: author, displayName: 作者, columnType: 1, columnLength: 50}
+      - {columnName: status, displayName: 状态, columnType: 11, enumJson: "draft,published,offline", zeroValue: draft}
+      - {columnName: publish_time, displayName: 发布时间, columnType: 3}
+      - {columnName: view_count, displayName: 浏览量, columnType: 6, zeroValue: "0", updateType: 4}
+    indexes:
+      - indexName: idx_article_category_status
+        columns: [{columnName: category_id}, {columnName: status}]
+  - tableName: tag
+    tableComment: 标签
+    recordType: 1
+    columns:
+      - {columnName: tag_name, displayName: 标签名, columnType: 1, columnLength: 50, zeroValue: "!NIL", uniqueCheck: 1}
+  - tableName: article_tag
+    tableComment: 文章标签
+    recordType: 1
+    columns:
+      - {columnName: article_id, displayName: 文章, columnType: 1, columnLength: 50, zeroValue: "!NIL", uniqueCheck: 1}
+      - {columnName: tag_id, displayName: 标签, columnType: 1, columnLength: 50, zeroValue: "!NIL", uniqueCheck: 1}
+relations:
+  - {relationName: category, sourceTable: article, sourceColumn: category_id, targetTable: category, cardinality: 2}
+  - {relationName: article, sourceTable: article_tag, sourceColumn: article_id, targetTable: article, cardinality: 2, onDelete: 2}
+  - {relationName: tag, sourceTable: article_tag, sourceColumn: tag_id, targetTable: tag, cardinality: 2, onDelete: 2}
+`,
+	},
+	{
+		name: "订单管理",
+		desc: "客户、商品、订单及订单明细，适用于简单的进销存、电商后台",
+		manifest: `
+manifestVersion: 1
+application:
+  appName: order
+  package: order
+tables:
+  - tableName: customer
+    tableComment: 客户
+    recordType: 3
+    columns:
+      - {columnName: customer_name, displayName: 客户名称, columnType: 1, columnLength: 100, zeroValue: "!NIL", stringSearch: 2}
+      - {columnName: phone, displayName: 联系电话, columnType: 1, columnLength: 20, uniqueCheck: 1}
+      - {columnName: address, displayName: 地址, columnType: 1, columnLength: 255}
+  - tableName: product
+    tableComment: 商品
+    recordType: 7
+    columns:
+      - {columnName: product_code, displayName: 商品编码, columnType: 1, columnLength: 50, zeroValue: "!NIL", uniqueCheck: 1, stringSearch: 3}
+      - {columnName: product_name, displayName: 商品名称, columnType: 1, columnLength: 100, zeroValue: "!NIL", stringSearch: 2}
+      - {columnName: price, displayName: 单价, columnType: 4, columnLength: 12, decimalLength: 2, zeroValue: "!NIL"}
+      - {columnName: stock, displayName: 库存, columnType: 2, zeroValue: "0"}
+      - {columnName: on_sale, displayName: 是否上架, columnType: 5, zeroValue: "true"}
+  - tableName: orders
+    tableComment: 订单
+    recordType: 3
+    columns:
+      - {columnName: order_no, displayName: 订单号, columnType: 1, columnLength: 32, zeroValue: "!NIL", uniqueCheck: 1, stringSearch: 3, updateType: 5}
+      - {columnName: customer_id, displayName: 客户, columnType: 1, columnLength: 50, zeroValue: "!NIL"}
+      - {columnName: total_amount, displayName: 订单金额, columnType: 4, columnLength: 12, decimalLength: 2, zeroValue: "!NIL"}
+      - {columnName: status, displayName: 订单状态, columnType: 11, enumJson: "pending,paid,shipped,completed,canceled", zeroValue: pending, updateAlone: 1}
+      - {columnName: order_time, displayName: 下单时间, columnType: 3, zeroValue: "!NIL"}
+      - {columnName: remark, displayName: 备注, columnType: 1, columnLength: 500, displayType: 11}
+    indexes:
+      - indexName: idx_orders_customer_time
+        columns: [{columnName: customer_id}, {columnName: order_time}]
+  - tableName: order_item
+    tableComment: 订单明细
+    recordType: 1
+    columns:
+      - {columnName: order_id, displayName: 订单, columnType: 1, columnLength: 50, zeroValue: "!NIL"}
+      - {columnName: product_id, displayName: 商品, columnType: 1, columnLength: 50, zeroValue: "!NIL"}
+      - {columnName: quantity, displayName: 数量, columnType: 2, zeroValue: "!NIL"}
+      - {columnName: unit_price, displayName: 成交单价, columnType: 4, columnLength: 12, decimalLength: 2, zeroValue: "!NIL"}
+    indexes:
+      - indexName: idx_order_item_order
+        columns: [{columnName: order_id}]
+relations:
+  - {relationName: customer, sourceTable: orders, sourceColumn: customer_id, targetTable: customer, cardinality: 2}
+  - {relationName: order, sourceTable: order_item, sourceColumn: order_id, targetTable: orders, cardinality: 2, onDelete: 2}
+  - {relationName: product, sourceTable: order_item, sourceColumn: product_id, targetTable: product, cardinality: 2}
+`,
+	},
+	{
+		name: "会员管理",
+		desc: "会员、会员等级及积分记录，适用于会员体系、积分商城等应用",
+		manifest: `
+manifestVersion: 1
+application:
+  appName: member
+  package: member
+tables:
+  - tableName: member_level
+    tableComment: 会员等级
+    recordType: 3
+    columns:
+      - {columnName: level_name, displayName: 等级名称, columnType: 1, columnLength: 50, zeroValue: "!NIL", uniqueCheck: 1}
+      - {columnName: min_points, displayName: 所需积分, columnType: 6, zeroValue: "0"}
+      - {columnName: discount, displayName: 折扣, columnType: 4, columnLength: 3, decimalLength: 2, zeroValue: "1"}
+  - tableName: member
+    tableComment: 会员
+    recordType: 7
+    columns:
+      - {columnName: member_no, displayName: 会员号, columnType: 1, columnLength: 32, zeroValue: "!NIL", uniqueCheck: 1, stringSearch: 3, updateType: 5}
+      - {columnName: nickname, displayName: 昵称, columnType: 1, columnLength: 50, stringSearch: 2}
+      - {columnName: mobile, displayName: 手机号, columnType: 1, columnLength: 20, zeroValue: "!NIL", uniqueCheck: 2, stringSearch: 3}
+      - {columnName: avatar, displayName: 头像, columnType: 12}
+      - {columnName: gender, displayName: 性别, columnType: 2, enumJson: '[{"key":0,"value":"未知"},{"key":1,"value":"男"},{"key":2,"value":"女"}]', zeroValue: "0"}
+      - {columnName: birthday, displayName: 生日, columnType: 8}
+      - {columnName: level_id, displayName: 会员等级, columnType: 1, columnLength: 50}
+      - {columnName: points, displayName: 积分, columnType: 6, zeroValue: "0", updateAlone: 1}
+  - tableName: point_record
+    tableComment: 积分记录
+    recordType: 1
+    columns:
+      - {columnName: member_id, displayName: 会员, columnType: 1, columnLength: 50, zeroValue: "!NIL"}
+      - {columnName: change_points, displayName: 变动积分, columnType: 6, zeroValue: "!NIL"}
+      - {columnName: reason, displayName: 变动原因, columnType: 1, columnLength: 200}
+    indexes:
+      - indexName: idx_point_record_member
+        columns: [{columnName: member_id}]
+relations:
+  - {relationName: level, sourceTable: member, sourceColumn: level_id, targetTable: member_level, cardinality: 2, onDelete: 3}
+  - {relationName: member, sourceTable: point_record, sourceColumn: member_id, targetTable: member, cardinality: 2, onDelete: 2}
+`,
+	},
+}
+
+// checkBuiltinTemplates 写入或更新内置应用模板
+func checkBuiltinTemplates() {
+	templates := make([]*model.ApplicationTemplate, 0, len(builtinTemplates))
+	for _, bt := range builtinTemplates {
+		manifest := new(model.ApplicationManifest)
+		if err := yaml.Unmarshal([]byte(bt.manifest), manifest); err != nil {
+			logger.Error(err)
+			return
+		}
+		templates = append(templates, &model.ApplicationTemplate{
+			TemplateName: bt.name,
+			TemplateDesc: bt.desc,
+			Manifest:     manifest,
+		})
+	}
+	if err := service.ApplicationTemplateService.EnsureBuiltin(templates); err != nil {
+		logger.Error(err)
+	}
+}
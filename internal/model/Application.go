@@ -7,6 +7,7 @@ import (
 const (
 	ApplicationIdPrefix       = "application"
 	ApplicationConfigIdPrefix = "applicationConfig"
+	ApplicationSourceIdPrefix = "applicationSource"
 )
 
 type Application struct {
@@ -16,15 +17,63 @@ type Application struct {
 	AppDesc    string          `json:"appDesc,omitempty" xorm:"varchar(500) comment('应用说明')"`
 	OwnerId    string          `json:"ownerId,omitempty" xorm:"varchar(50) comment('创建人/所有人ID')"`
 	CreateTime domain.DateTime `json:"createTime" xorm:"created"`
+	TemplateId string          `json:"templateId,omitempty" xorm:"-"` // 新增时指定，从模板创建表及字段
 }
 
 type ApplicationSource struct {
-	Id            string          `json:"id,omitempty" xorm:"pk varchar(50)"`
-	ApplicationId string          `json:"applicationId,omitempty" xorm:"index varchar(50)"`
-	Source        []byte          `json:"source,omitempty" xorm:"blob"`
-	CreateTime    domain.DateTime `json:"createTime" xorm:"created"`
+	Id            string               `json:"id,omitempty" xorm:"pk varchar(50)"`
+	ApplicationId string               `json:"applicationId,omitempty" xorm:"unique(application_version) varchar(50)"`
+	Version       int                  `json:"version,omitempty" xorm:"unique(application_version) comment('生成版本号，同一应用内递增')"`
+	ReleaseNote   string               `json:"releaseNote,omitempty" xorm:"varchar(500) comment('版本说明')"`
+	Snapshot      *ApplicationSnapshot `json:"snapshot,omitempty" xorm:"longtext json comment('生成时使用的表及字段配置快照')"`
+	Source        []byte               `json:"source,omitempty" xorm:"blob"`
+	Size          int64                `json:"size" xorm:"comment('源码包大小(字节)')"`
+	Broken        bool                 `json:"broken,omitempty" xorm:"comment('生成的代码是否未通过校验')"`
+	CheckResult   *SourceCheckResult   `json:"checkResult,omitempty" xorm:"longtext json comment('生成代码的校验结果')"`
+	CreateTime    domain.DateTime      `json:"createTime" xorm:"created"`
 }
 
+// ApplicationSnapshot 生成代码时应用的表及字段配置快照
+type ApplicationSnapshot struct {
+	Config        *ApplicationConfig `json:"config,omitempty"`
+	Tables        []*TableSnapshot   `json:"tables"`
+	Relations     []*TableRelation   `json:"relations,omitempty"`
+	CodeTemplates []*CodeTemplate    `json:"codeTemplates,omitempty"`
+}
+
+type TableSnapshot struct {
+	*TableConfig
+	Columns []*ColumnConfig `json:"columns"`
+	Indexes []*TableIndex   `json:"indexes,omitempty"`
+}
+
+const (
+	SourceFileAdded   = "added"
+	SourceFileRemoved = "removed"
+	SourceFileChanged = "changed"
+)
+
+// SourceFileDiff 两个生成版本之间单个文件的差异
+type SourceFileDiff struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// 要生成的页面类型，可按位组合
+const (
+	PageTypePC        = 1
+	PageTypeMobile    = 2
+	PageTypeBigScreen = 4
+	PageTypeAll       = PageTypePC | PageTypeMobile | PageTypeBigScreen
+)
+
+// 应用未配置时生成代码使用的默认值
+const (
+	DefaultPageType         = PageTypePC
+	DefaultTokenExpireHours = 24
+)
+
 type ApplicationConfig struct {
 	Id               string `json:"id,omitempty" xorm:"pk varchar(50)"`
 	ApplicationId    string `json:"applicationId,omitempty" xorm:"index varchar(50)"`
@@ -41,6 +90,19 @@ type ApplicationRequest struct {
 	CreateTimeRange *domain.TimeCondition `json:"createTimeRange,omitempty"`
 }
 
+type GenerateRequest struct {
+	ReleaseNote string `json:"releaseNote,omitempty"`
+	Force       bool   `json:"force,omitempty"` // 设计校验存在错误时仍然生成
+}
+
 type ApplicationConfigRequest struct {
 	*ApplicationConfig
 }
+
+// ApplicationCloneRequest 复制应用的请求，新应用使用新的应用名及包名
+type ApplicationCloneRequest struct {
+	Id      string `json:"id,omitempty"`
+	AppName string `json:"appName,omitempty"`
+	Package string `json:"package,omitempty"`
+	AppDesc string `json:"appDesc,omitempty"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"github.com/yockii/qscore/pkg/domain"
+)
+
+const (
+	ApplicationRuntimeIdPrefix = "applicationRuntime"
+)
+
+const (
+	RuntimeStatusRunning = 1
+	RuntimeStatusStopped = 2
+)
+
+// RuntimeRoutePrefix 运行时接口的路由前缀，完整路由为 /runtime/{RoutePrefix}/{表名小驼峰}
+const RuntimeRoutePrefix = "/runtime"
+
+// ApplicationRuntime 应用的运行时模式，按表设计直接建表并提供增删改查接口，无需生成代码。
+// Snapshot为最近一次部署的表设计，运行时接口及物理表均以此为准
+type ApplicationRuntime struct {
+	Id            string               `json:"id,omitempty" xorm:"pk varchar(50)"`
+	ApplicationId string               `json:"applicationId,omitempty" xorm:"unique varchar(50)"`
+	RoutePrefix   string               `json:"routePrefix,omitempty" xorm:"unique varchar(50) comment('路由前缀')"`
+	TablePrefix   string               `json:"tablePrefix,omitempty" xorm:"unique varchar(60) comment('物理表名前缀，首次部署时由应用ID确定')"`
+	Status        int                  `json:"status,omitempty" xorm:"comment('状态 1-运行中 2-已停止')"`
+	Snapshot      *ApplicationSnapshot `json:"snapshot,omitempty" xorm:"longtext json comment('部署时的表设计快照')"`
+	DeployTime    domain.DateTime      `json:"deployTime" xorm:"comment('最近部署时间')"`
+	OwnerId       string               `json:"ownerId,omitempty" xorm:"varchar(50) comment('部署人ID')"`
+	CreateTime    domain.DateTime      `json:"createTime" xorm:"created"`
+}
+
+func init() {
+	SyncModels = append(SyncModels, ApplicationRuntime{})
+}
+
+type ApplicationRuntimeRequest struct {
+	*ApplicationRuntime
+	CreateTimeRange *domain.TimeCondition `json:"createTimeRange,omitempty"`
+}
+
+// RuntimeDeployRequest 部署请求，表设计的变更存在破坏性操作(删除表、字段等)时需Force为true才会执行
+type RuntimeDeployRequest struct {
+	ApplicationId string `json:"applicationId"`
+	RoutePrefix   string `json:"routePrefix,omitempty"` // 为空时使用已有前缀，首次部署时使用应用包名
+	Force         bool   `json:"force,omitempty"`
+}
+
+// RuntimeDeployResult 部署结果，Applied为false时表示存在破坏性变更未执行
+type RuntimeDeployResult struct {
+	Runtime *ApplicationRuntime `json:"runtime,omitempty"`
+	Script  *MigrationScript    `json:"script"`
+	Applied bool                `json:"applied"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/yockii/qscore/pkg/domain"
+)
+
+const (
+	ApplicationTemplateIdPrefix = "applicationTemplate"
+)
+
+// ApplicationTemplate 应用模板，保存表及字段设计，用于快速创建新应用
+type ApplicationTemplate struct {
+	Id           string               `json:"id,omitempty" xorm:"pk varchar(50)"`
+	TemplateName string               `json:"templateName,omitempty" xorm:"varchar(100) comment('模板名称')"`
+	TemplateDesc string               `json:"templateDesc,omitempty" xorm:"varchar(500) comment('模板说明')"`
+	Builtin      int                  `json:"builtin,omitempty" xorm:"comment('是否内置模板 0-否 1-是')"`
+	TableCount   int                  `json:"tableCount,omitempty" xorm:"comment('包含的表数量')"`
+	Manifest     *ApplicationManifest `json:"manifest,omitempty" xorm:"longtext json comment('模板内容')"`
+	OwnerId      string               `json:"ownerId,omitempty" xorm:"varchar(50) comment('创建人ID')"`
+	CreateTime   domain.DateTime      `json:"createTime" xorm:"created"`
+}
+
+func init() {
+	SyncModels = append(SyncModels, ApplicationTemplate{})
+}
+
+type ApplicationTemplateRequest struct {
+	*ApplicationTemplate
+	CreateTimeRange *domain.TimeCondition `json:"createTimeRange,omitempty"`
+}
+
+// SaveTemplateRequest 将已有应用的设计保存为模板
+type SaveTemplateRequest struct {
+	ApplicationId string `json:"applicationId"`
+	TemplateName  string `json:"templateName"`
+	TemplateDesc  string `json:"templateDesc,omitempty"`
+}
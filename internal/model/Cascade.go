@@ -0,0 +1,10 @@
+package model
+
+// CascadeDeleteResult 级联删除或清理孤立记录的结果，DryRun时仅统计不删除
+type CascadeDeleteResult struct {
+	DryRun        bool             `json:"dryRun"`
+	Total         int64            `json:"total"`
+	Counts        map[string]int64 `json:"counts"`                  // 数据表 -> 删除(或将删除)的记录数
+	DroppedTables []string         `json:"droppedTables,omitempty"` // 一并删除的运行时数据表
+	Warnings      []string         `json:"warnings,omitempty"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"github.com/yockii/qscore/pkg/domain"
+)
+
+const (
+	CodeTemplateIdPrefix = "codeTemplate"
+)
+
+// CodeTemplate 应用自定义的代码模板(text/template)，按文件类型保存，同一应用同一文件类型每次上传生成新版本。
+// 当前依赖的生成器不支持替换模板，生成代码时不会使用，仅记录在设计快照中并可按表预览渲染结果
+type CodeTemplate struct {
+	Id            string          `json:"id,omitempty" xorm:"pk varchar(50)"`
+	ApplicationId string          `json:"applicationId,omitempty" form:"applicationId" xorm:"unique(application_kind_version) varchar(50)"`
+	Kind          string          `json:"kind,omitempty" form:"kind" xorm:"unique(application_kind_version) varchar(50) comment('文件类型')"`
+	Version       int             `json:"version,omitempty" xorm:"unique(application_kind_version) comment('模板版本号，同一应用同一文件类型内递增')"`
+	Content       string          `json:"content,omitempty" form:"content" xorm:"longtext comment('模板内容')"` // 也可通过file上传
+	Remark        string          `json:"remark,omitempty" form:"remark" xorm:"varchar(500) comment('版本说明')"`
+	OwnerId       string          `json:"ownerId,omitempty" xorm:"varchar(50) comment('上传人ID')"`
+	CreateTime    domain.DateTime `json:"createTime" xorm:"created"`
+}
+
+func init() {
+	SyncModels = append(SyncModels, CodeTemplate{})
+}
+
+type CodeTemplateRequest struct {
+	*CodeTemplate
+	CreateTimeRange *domain.TimeCondition `json:"createTimeRange,omitempty"`
+}
+
+// CodeTemplatePreviewRequest 以一张表渲染模板，Content为空时使用该文件类型的最新版本
+type CodeTemplatePreviewRequest struct {
+	ApplicationId string `json:"applicationId"`
+	TableId       string `json:"tableId"`
+	Kind          string `json:"kind"`
+	Content       string `json:"content,omitempty"`
+}
+
+// CodeTemplatePreviewResult 模板渲染结果，模板有错误时Error不为空
+type CodeTemplatePreviewResult struct {
+	Kind   string `json:"kind"`
+	Table  string `json:"table"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
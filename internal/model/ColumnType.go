@@ -0,0 +1,48 @@
+package model
+
+// 前端控件提示
+const (
+	ControlInput    = "input"
+	ControlTextarea = "textarea"
+	ControlNumber   = "number"
+	ControlSwitch   = "switch"
+	ControlDateTime = "datetime"
+	ControlDate     = "date"
+	ControlTime     = "time"
+	ControlJson     = "json"
+	ControlSelect   = "select"
+	ControlUpload   = "upload"
+)
+
+// ColumnTypeSpec 字段类型对应的Go类型及前端控件
+type ColumnTypeSpec struct {
+	Type    int    `json:"type"`
+	Name    string `json:"name"`
+	GoType  string `json:"goType"`
+	Control string `json:"control"`
+}
+
+var ColumnTypeSpecs = []*ColumnTypeSpec{
+	{Type: ColumnTypeString, Name: "string", GoType: "string", Control: ControlInput},
+	{Type: ColumnTypeInt, Name: "int", GoType: "int", Control: ControlNumber},
+	{Type: ColumnTypeDateTime, Name: "datetime", GoType: "time.Time", Control: ControlDateTime},
+	{Type: ColumnTypeDecimal, Name: "decimal", GoType: "float64", Control: ControlNumber},
+	{Type: ColumnTypeBool, Name: "bool", GoType: "bool", Control: ControlSwitch},
+	{Type: ColumnTypeBigInt, Name: "bigint", GoType: "int64", Control: ControlNumber},
+	{Type: ColumnTypeFloat, Name: "float", GoType: "float64", Control: ControlNumber},
+	{Type: ColumnTypeDate, Name: "date", GoType: "time.Time", Control: ControlDate},
+	{Type: ColumnTypeTime, Name: "time", GoType: "string", Control: ControlTime},
+	{Type: ColumnTypeJson, Name: "json", GoType: "json.RawMessage", Control: ControlJson},
+	{Type: ColumnTypeEnum, Name: "enum", GoType: "string", Control: ControlSelect},
+	{Type: ColumnTypeFile, Name: "file", GoType: "string", Control: ControlUpload},
+}
+
+// GetColumnTypeSpec 获取字段类型的定义，未知类型返回nil
+func GetColumnTypeSpec(columnType int) *ColumnTypeSpec {
+	for _, spec := range ColumnTypeSpecs {
+		if spec.Type == columnType {
+			return spec
+		}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package model
+
+// 数据字典的导出格式
+const (
+	DictionaryFormatMarkdown = "markdown"
+	DictionaryFormatHtml     = "html"
+	DictionaryFormatXlsx     = "xlsx"
+)
@@ -0,0 +1,16 @@
+package model
+
+// ER图的输出格式
+const (
+	ErFormatMermaid  = "mermaid"
+	ErFormatPlantUml = "plantuml"
+	ErFormatDot      = "dot"
+)
+
+// ErDiagramOptions ER图选项，Tables为表ID或表名，为空时包含全部表；
+// HideAudit为true时不显示创建、更新及删除时间字段
+type ErDiagramOptions struct {
+	Format    string   `json:"format,omitempty"`
+	Tables    []string `json:"tables,omitempty"`
+	HideAudit bool     `json:"hideAudit,omitempty"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"github.com/yockii/qscore/pkg/domain"
+)
+
+const (
+	GenerationJobIdPrefix = "generationJob"
+)
+
+const (
+	GenerationJobStatusQueued    = 1
+	GenerationJobStatusRunning   = 2
+	GenerationJobStatusSucceeded = 3
+	GenerationJobStatusFailed    = 4
+	GenerationJobStatusCanceled  = 5
+)
+
+type GenerationJob struct {
+	Id            string             `json:"id,omitempty" xorm:"pk varchar(50)"`
+	ApplicationId string             `json:"applicationId,omitempty" xorm:"index varchar(50)"`
+	Status        int                `json:"status,omitempty" xorm:"comment('任务状态 1-排队中 2-执行中 3-成功 4-失败 5-已取消')"`
+	ReleaseNote   string             `json:"releaseNote,omitempty" xorm:"varchar(500) comment('版本说明')"`
+	SourceId      string             `json:"sourceId,omitempty" xorm:"varchar(50) comment('生成成功后的源码包ID')"`
+	ErrorMsg      string             `json:"errorMsg,omitempty" xorm:"text comment('失败原因')"`
+	CheckResult   *SourceCheckResult `json:"checkResult,omitempty" xorm:"longtext json comment('生成代码的校验结果')"`
+	CreateTime    domain.DateTime    `json:"createTime" xorm:"created"`
+	UpdateTime    domain.DateTime    `json:"updateTime" xorm:"updated"`
+}
+
+func init() {
+	SyncModels = append(SyncModels, GenerationJob{})
+}
@@ -0,0 +1,23 @@
+package model
+
+const (
+	LintLevelError   = "error"
+	LintLevelWarning = "warning"
+)
+
+// LintIssue 设计校验发现的问题
+type LintIssue struct {
+	Level      string `json:"level"`
+	Rule       string `json:"rule"`
+	TableId    string `json:"tableId,omitempty"`
+	TableName  string `json:"tableName,omitempty"`
+	ColumnId   string `json:"columnId,omitempty"`
+	ColumnName string `json:"columnName,omitempty"`
+	Message    string `json:"message"`
+}
+
+type LintResult struct {
+	Errors   int          `json:"errors"`
+	Warnings int          `json:"warnings"`
+	Issues   []*LintIssue `json:"issues"`
+}
@@ -0,0 +1,97 @@
+package model
+
+// CurrentManifestVersion 当前应用定义清单的格式版本
+const CurrentManifestVersion = 1
+
+const (
+	ManifestImportCreate = "create" // 创建新应用
+	ManifestImportMerge  = "merge"  // 合并到已有应用
+)
+
+// ApplicationManifest 可移植的应用定义清单，不包含任何数据库ID
+type ApplicationManifest struct {
+	ManifestVersion int                  `json:"manifestVersion" yaml:"manifestVersion"`
+	Application     *ManifestApplication `json:"application" yaml:"application"`
+	Config          *ManifestConfig      `json:"config,omitempty" yaml:"config,omitempty"`
+	Tables          []*ManifestTable     `json:"tables" yaml:"tables"`
+	Relations       []*ManifestRelation  `json:"relations,omitempty" yaml:"relations,omitempty"`
+	CodeTemplates   []*ManifestTemplate  `json:"codeTemplates,omitempty" yaml:"codeTemplates,omitempty"`
+}
+
+type ManifestApplication struct {
+	AppName string `json:"appName" yaml:"appName"`
+	Package string `json:"package" yaml:"package"`
+	AppDesc string `json:"appDesc,omitempty" yaml:"appDesc,omitempty"`
+}
+
+type ManifestConfig struct {
+	PageType         int `json:"pageType,omitempty" yaml:"pageType,omitempty"`
+	TokenExpireHours int `json:"tokenExpireHours,omitempty" yaml:"tokenExpireHours,omitempty"`
+}
+
+type ManifestTable struct {
+	TableName    string            `json:"tableName" yaml:"tableName"`
+	TableComment string            `json:"tableComment,omitempty" yaml:"tableComment,omitempty"`
+	RecordType   int               `json:"recordType,omitempty" yaml:"recordType,omitempty"`
+	Columns      []*ManifestColumn `json:"columns" yaml:"columns"`
+	Indexes      []*ManifestIndex  `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+}
+
+type ManifestColumn struct {
+	ColumnName    string `json:"columnName" yaml:"columnName"`
+	DisplayName   string `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	ColumnComment string `json:"columnComment,omitempty" yaml:"columnComment,omitempty"`
+	DisplayType   int    `json:"displayType,omitempty" yaml:"displayType,omitempty"`
+	ColumnType    int    `json:"columnType,omitempty" yaml:"columnType,omitempty"`
+	UpdateType    int    `json:"updateType,omitempty" yaml:"updateType,omitempty"`
+	UpdateAlone   int    `json:"updateAlone,omitempty" yaml:"updateAlone,omitempty"`
+	ZeroValue     string `json:"zeroValue,omitempty" yaml:"zeroValue,omitempty"`
+	UniqueCheck   int    `json:"uniqueCheck,omitempty" yaml:"uniqueCheck,omitempty"`
+	StringType    int    `json:"stringType,omitempty" yaml:"stringType,omitempty"`
+	StringSearch  int    `json:"stringSearch,omitempty" yaml:"stringSearch,omitempty"`
+	EnumJson      string `json:"enumJson,omitempty" yaml:"enumJson,omitempty"`
+	ColumnLength  int    `json:"columnLength,omitempty" yaml:"columnLength,omitempty"`
+	DecimalLength int    `json:"decimalLength,omitempty" yaml:"decimalLength,omitempty"`
+}
+
+// ManifestIndex 表索引，以字段名引用
+type ManifestIndex struct {
+	IndexName string                 `json:"indexName" yaml:"indexName"`
+	IsUnique  int                    `json:"isUnique,omitempty" yaml:"isUnique,omitempty"`
+	Columns   []*ManifestIndexColumn `json:"columns" yaml:"columns"`
+}
+
+type ManifestIndexColumn struct {
+	ColumnName   string `json:"columnName" yaml:"columnName"`
+	PrefixLength int    `json:"prefixLength,omitempty" yaml:"prefixLength,omitempty"`
+}
+
+// ManifestRelation 表关联，以表名及字段名引用
+type ManifestRelation struct {
+	RelationName string `json:"relationName,omitempty" yaml:"relationName,omitempty"`
+	SourceTable  string `json:"sourceTable" yaml:"sourceTable"`
+	SourceColumn string `json:"sourceColumn,omitempty" yaml:"sourceColumn,omitempty"`
+	TargetTable  string `json:"targetTable" yaml:"targetTable"`
+	Cardinality  int    `json:"cardinality" yaml:"cardinality"`
+	OnDelete     int    `json:"onDelete,omitempty" yaml:"onDelete,omitempty"`
+	Comment      string `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+// ManifestTemplate 自定义代码模板，仅包含最新版本
+type ManifestTemplate struct {
+	Kind    string `json:"kind" yaml:"kind"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// ManifestImportResult 清单导入结果
+type ManifestImportResult struct {
+	Application    *Application `json:"application,omitempty"`
+	Conflicts      []string     `json:"conflicts,omitempty"` // 存在冲突时不导入任何内容
+	TablesCreated  int          `json:"tablesCreated"`
+	TablesUpdated  int          `json:"tablesUpdated"`
+	ColumnsCreated int          `json:"columnsCreated"`
+	ColumnsUpdated int          `json:"columnsUpdated"`
+	IndexesAdded   int          `json:"indexesAdded"`
+	RelationsAdded int          `json:"relationsAdded"`
+	TemplatesAdded int          `json:"templatesAdded"`
+}
@@ -0,0 +1,42 @@
+package model
+
+// 迁移脚本支持的数据库类型
+const (
+	SqlDialectMysql    = "mysql"
+	SqlDialectPostgres = "postgres"
+)
+
+// 迁移步骤的操作类型
+const (
+	MigrationCreateTable  = "create-table"
+	MigrationRenameTable  = "rename-table"
+	MigrationAlterTable   = "alter-table"
+	MigrationDropTable    = "drop-table"
+	MigrationAddColumn    = "add-column"
+	MigrationModifyColumn = "modify-column"
+	MigrationDropColumn   = "drop-column"
+	MigrationCreateIndex  = "create-index"
+	MigrationDropIndex    = "drop-index"
+)
+
+// MigrationStep 迁移脚本中的一个步骤，Destructive表示该步骤会丢失数据
+type MigrationStep struct {
+	Action      string   `json:"action"`
+	Table       string   `json:"table"`
+	Column      string   `json:"column,omitempty"`
+	Index       string   `json:"index,omitempty"`
+	Statements  []string `json:"statements"`
+	Destructive bool     `json:"destructive,omitempty"`
+	Warning     string   `json:"warning,omitempty"`
+}
+
+// MigrationScript 两个设计版本之间的数据库迁移脚本。
+// FromVersion为0表示从空数据库开始，ToVersion为0表示当前尚未生成的设计
+type MigrationScript struct {
+	Dialect     string           `json:"dialect"`
+	FromVersion int              `json:"fromVersion"`
+	ToVersion   int              `json:"toVersion"`
+	Steps       []*MigrationStep `json:"steps"`
+	Destructive int              `json:"destructive"`
+	Script      string           `json:"script"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// 模拟数据的导出格式
+const (
+	MockFormatSql  = "sql"
+	MockFormatCsv  = "csv"
+	MockFormatJson = "json"
+)
+
+const (
+	MockDefaultRows = 10
+	MockMaxRows     = 1000
+)
+
+// MockTable 一张表的模拟数据，Rows中每行的值与Columns一一对应，nil表示NULL
+type MockTable struct {
+	TableName string          `json:"tableName"`
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+}
+
+// MockDataSet 模拟数据，相同的设计、行数及Seed生成的数据相同
+type MockDataSet struct {
+	Seed   int64        `json:"seed"`
+	Tables []*MockTable `json:"tables"`
+}
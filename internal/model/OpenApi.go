@@ -0,0 +1,89 @@
+package model
+
+// OpenApiVersion 导出的接口文档遵循的OpenAPI版本
+const OpenApiVersion = "3.0.3"
+
+// OpenApiDocument OpenAPI 3 接口文档，仅包含生成代码用到的部分
+type OpenApiDocument struct {
+	Openapi    string                      `json:"openapi" yaml:"openapi"`
+	Info       *OpenApiInfo                `json:"info" yaml:"info"`
+	Tags       []*OpenApiTag               `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      map[string]*OpenApiPathItem `json:"paths" yaml:"paths"`
+	Components *OpenApiComponents          `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type OpenApiInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type OpenApiTag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type OpenApiPathItem struct {
+	Get    *OpenApiOperation `json:"get,omitempty" yaml:"get,omitempty"`
+	Post   *OpenApiOperation `json:"post,omitempty" yaml:"post,omitempty"`
+	Put    *OpenApiOperation `json:"put,omitempty" yaml:"put,omitempty"`
+	Delete *OpenApiOperation `json:"delete,omitempty" yaml:"delete,omitempty"`
+}
+
+type OpenApiOperation struct {
+	Tags        []string                    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	OperationId string                      `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []*OpenApiParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenApiResponse `json:"responses" yaml:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+type OpenApiParameter struct {
+	Name        string         `json:"name" yaml:"name"`
+	In          string         `json:"in" yaml:"in"` // query/path
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *OpenApiSchema `json:"schema" yaml:"schema"`
+}
+
+type OpenApiRequestBody struct {
+	Required bool                         `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*OpenApiMediaType `json:"content" yaml:"content"`
+}
+
+type OpenApiResponse struct {
+	Description string                       `json:"description" yaml:"description"`
+	Content     map[string]*OpenApiMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type OpenApiMediaType struct {
+	Schema *OpenApiSchema `json:"schema" yaml:"schema"`
+}
+
+type OpenApiComponents struct {
+	Schemas         map[string]*OpenApiSchema         `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]*OpenApiSecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+type OpenApiSecurityScheme struct {
+	Type         string `json:"type" yaml:"type"`
+	Scheme       string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+}
+
+type OpenApiSchema struct {
+	Ref         string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type        string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format      string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Title       string                    `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string                    `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum        []interface{}             `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default     interface{}               `json:"default,omitempty" yaml:"default,omitempty"`
+	MaxLength   int                       `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	ReadOnly    bool                      `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	Required    []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Properties  map[string]*OpenApiSchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Items       *OpenApiSchema            `json:"items,omitempty" yaml:"items,omitempty"`
+}
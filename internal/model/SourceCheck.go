@@ -0,0 +1,31 @@
+package model
+
+// 生成代码校验的阶段
+const (
+	SourceCheckStageParse  = "parse"  // 语法解析
+	SourceCheckStageFormat = "format" // gofmt格式
+	SourceCheckStageType   = "type"   // 类型检查
+)
+
+// SourceCheckMaxIssues 校验结果最多保留的问题数
+const SourceCheckMaxIssues = 200
+
+// SourceCheckIssue 生成代码中单个文件的问题，Level为error时源码包视为损坏
+type SourceCheckIssue struct {
+	Level   string `json:"level"`
+	Stage   string `json:"stage"`
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// SourceCheckResult 生成代码的校验结果
+type SourceCheckResult struct {
+	Files     int                 `json:"files"`    // 校验的go文件数
+	Packages  int                 `json:"packages"` // 完成类型检查的包数
+	Errors    int                 `json:"errors"`
+	Warnings  int                 `json:"warnings"`
+	Truncated bool                `json:"truncated,omitempty"` // 问题过多，仅保留前SourceCheckMaxIssues条
+	Issues    []*SourceCheckIssue `json:"issues"`
+}
@@ -5,12 +5,67 @@ const (
 	ColumnConfigIdPrefix = "columnConfig"
 )
 
+const (
+	RecordTypeCreateTime = 1
+	RecordTypeUpdateTime = 2
+	RecordTypeDeleteTime = 4
+)
+
+const (
+	ColumnTypeString   = 1
+	ColumnTypeInt      = 2
+	ColumnTypeDateTime = 3
+	ColumnTypeDecimal  = 4
+	ColumnTypeBool     = 5
+	ColumnTypeBigInt   = 6
+	ColumnTypeFloat    = 7
+	ColumnTypeDate     = 8
+	ColumnTypeTime     = 9
+	ColumnTypeJson     = 10
+	ColumnTypeEnum     = 11 // 文本枚举，枚举值以,分割存放在EnumJson中
+	ColumnTypeFile     = 12 // 文件/附件引用，存储文件地址
+)
+
+const (
+	StringTypeVarchar  = 1
+	StringTypeLongtext = 2
+)
+
+// 字段显示类型，可按位组合，0表示未设置，按全部显示处理
+const (
+	DisplayTypeAdd    = 1
+	DisplayTypeUpdate = 2
+	DisplayTypeList   = 4
+	DisplayTypeDetail = 8
+	DisplayTypeAll    = DisplayTypeAdd | DisplayTypeUpdate | DisplayTypeList | DisplayTypeDetail
+)
+
+// 字段更新方式，可按位组合
+const (
+	UpdateTypeCreate = 1
+	UpdateTypeUpdate = 2
+	UpdateTypeSearch = 4
+)
+
+const (
+	StringSearchPrefix = 1
+	StringSearchFull   = 2
+	StringSearchExact  = 3
+)
+
+// UpdateAloneOnly 字段只能通过单独的接口更改
+const UpdateAloneOnly = 1
+
+// ZeroValueNotNull 字段不允许为空时ZeroValue的取值
+const ZeroValueNotNull = "!NIL"
+
 type TableConfig struct {
 	Id            string `json:"id,omitempty" xorm:"pk varchar(50)"`
 	ApplicationId string `json:"applicationId,omitempty" xorm:"index varchar(50)"`
 	TableName     string `json:"tableName,omitempty" xorm:"varchar(50) comment('表名')"`
 	TableComment  string `json:"tableComment,omitempty" xorm:"comment('表说明')"`
 	RecordType    int    `json:"recordType,omitempty" xorm:"comment('表记录类型 0-未设置 1-记录创建时间 2-记录更新时间 4-记录删除时间')"`
+	SortOrder     int    `json:"sortOrder,omitempty" xorm:"comment('应用内的排序，越小越靠前')"`
 }
 
 type ColumnConfig struct {
@@ -21,7 +76,7 @@ type ColumnConfig struct {
 	DisplayName   string `json:"displayName,omitempty" xorm:"comment('字段显示名')"`
 	ColumnComment string `json:"columnComment,omitempty" xorm:"comment('字段说明')"`
 	DisplayType   int    `json:"displayType,omitempty" xorm:"comment('显示类型 0-未设置 1-添加显示 2-更新显示 4-列表显示 8-详情显示')"`
-	ColumnType    int    `json:"columnType,omitempty" xorm:"comment('字段类型 0-未知 1-string 2-int 3-DateTime 4-decimal')"`
+	ColumnType    int    `json:"columnType,omitempty" xorm:"comment('字段类型 0-未知 1-string 2-int 3-DateTime 4-decimal 5-bool 6-bigint 7-float 8-date 9-time 10-json 11-enum 12-file')"`
 	UpdateType    int    `json:"updateType,omitempty" xorm:"comment('字段更新方式 0-未设置 1-允许新增 2-允许更改 4-允许作为查询条件')"`
 	UpdateAlone   int    `json:"updateAlone,omitempty" xorm:"comment('独立更改 0-未设置 1-只能独立更改 将会生成单独的接口进行更改')"`
 	ZeroValue     string `json:"zeroValue,omitempty" xorm:"comment('空时默认值 !NIL-表示不允许为空，如有其他值表示为空时采用该默认值')"`
@@ -31,6 +86,7 @@ type ColumnConfig struct {
 	EnumJson      string `json:"enumJson,omitempty" xorm:"comment('枚举值，如果是字符串类，以,分割，如果是int，则需存入json，格式为[{key:1,value:''}]')"`
 	ColumnLength  int    `json:"columnLength,omitempty" xorm:"comment('字段长度')"`
 	DecimalLength int    `json:"decimalLength,omitempty" xorm:"comment('小数部分长度')"`
+	SortOrder     int    `json:"sortOrder,omitempty" xorm:"comment('表内的排序，越小越靠前')"`
 }
 
 func init() {
@@ -43,3 +99,61 @@ type TableConfigRequest struct {
 type ColumnConfigRequest struct {
 	*ColumnConfig
 }
+
+// ReorderRequest 批量排序请求，Ids为按新顺序排列的全部表ID(指定ApplicationId时)或全部字段ID(指定TableId时)
+type ReorderRequest struct {
+	ApplicationId string   `json:"applicationId,omitempty"`
+	TableId       string   `json:"tableId,omitempty"`
+	Ids           []string `json:"ids"`
+}
+
+// EnumItem 字段的一个枚举值
+type EnumItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SchemaImportRequest 从已有数据库结构导入表配置的请求
+type SchemaImportRequest struct {
+	ApplicationId string   `json:"applicationId,omitempty"`
+	DataSource    string   `json:"dataSource,omitempty"` // 配置文件中登记的数据源名称，为空时使用系统数据库
+	Driver        string   `json:"driver,omitempty"`     // 直接指定数据源时的数据库驱动
+	Dsn           string   `json:"dsn,omitempty"`        // 直接指定的数据源连接串，仅超级管理员可用
+	Tables        []string `json:"tables,omitempty"`     // 仅导入指定的表，为空时导入全部
+	Preview       bool     `json:"preview,omitempty"`    // 仅预览，不写入
+}
+
+// SchemaImportResult 导入结果，预览时为将要创建的配置
+type SchemaImportResult struct {
+	Tables   []*TableSnapshot `json:"tables"`
+	Skipped  []string         `json:"skipped,omitempty"`  // 应用中已存在同名表而跳过的表
+	Warnings []string         `json:"warnings,omitempty"` // 无法准确映射的字段说明
+}
+
+// DdlImportRequest 从建表脚本导入表配置的请求
+type DdlImportRequest struct {
+	ApplicationId string `json:"applicationId,omitempty" form:"applicationId"`
+	Preview       bool   `json:"preview,omitempty" form:"preview"` // 仅预览，不写入
+	Script        string `json:"script,omitempty" form:"script"`   // 建表脚本，也可通过file上传
+}
+
+const (
+	DdlStatementImported = "imported" // 已导入
+	DdlStatementPreview  = "preview"  // 预览，未写入
+	DdlStatementSkipped  = "skipped"  // 应用中已存在同名表
+	DdlStatementIgnored  = "ignored"  // 不支持的语句
+)
+
+// DdlStatementReport 单条语句的导入报告
+type DdlStatementReport struct {
+	Index     int      `json:"index"`
+	Statement string   `json:"statement"`
+	Table     string   `json:"table,omitempty"`
+	Status    string   `json:"status"`
+	Messages  []string `json:"messages,omitempty"`
+}
+
+type DdlImportResult struct {
+	Tables     []*TableSnapshot      `json:"tables"`
+	Statements []*DdlStatementReport `json:"statements"`
+}
@@ -0,0 +1,28 @@
+package model
+
+const (
+	TableIndexIdPrefix = "tableIndex"
+)
+
+type TableIndex struct {
+	Id            string         `json:"id,omitempty" xorm:"pk varchar(50)"`
+	ApplicationId string         `json:"applicationId,omitempty" xorm:"index varchar(50)"`
+	TableId       string         `json:"tableId,omitempty" xorm:"index varchar(50)"`
+	IndexName     string         `json:"indexName,omitempty" xorm:"varchar(64) comment('索引名')"`
+	IsUnique      int            `json:"isUnique,omitempty" xorm:"comment('是否唯一索引 0-否 1-是')"`
+	Columns       []*IndexColumn `json:"columns,omitempty" xorm:"text json comment('按顺序排列的索引字段')"`
+}
+
+// IndexColumn 索引中的字段，PrefixLength仅对字符串字段有效，0表示整个字段
+type IndexColumn struct {
+	ColumnId     string `json:"columnId"`
+	PrefixLength int    `json:"prefixLength,omitempty"`
+}
+
+func init() {
+	SyncModels = append(SyncModels, TableIndex{})
+}
+
+type TableIndexRequest struct {
+	*TableIndex
+}
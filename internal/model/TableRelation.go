@@ -0,0 +1,37 @@
+package model
+
+const (
+	TableRelationIdPrefix = "tableRelation"
+)
+
+const (
+	RelationOneToMany  = 1
+	RelationManyToOne  = 2
+	RelationManyToMany = 3
+)
+
+const (
+	OnDeleteRestrict = 1
+	OnDeleteCascade  = 2
+	OnDeleteSetNull  = 3
+)
+
+type TableRelation struct {
+	Id             string `json:"id,omitempty" xorm:"pk varchar(50)"`
+	ApplicationId  string `json:"applicationId,omitempty" xorm:"index varchar(50)"`
+	RelationName   string `json:"relationName,omitempty" xorm:"varchar(50) comment('关联名称，生成代码中的关联字段名')"`
+	SourceTableId  string `json:"sourceTableId,omitempty" xorm:"index varchar(50) comment('源表ID')"`
+	SourceColumnId string `json:"sourceColumnId,omitempty" xorm:"varchar(50) comment('源表关联字段ID，多对多时可为空')"`
+	TargetTableId  string `json:"targetTableId,omitempty" xorm:"index varchar(50) comment('目标表ID')"`
+	Cardinality    int    `json:"cardinality,omitempty" xorm:"comment('关联类型 1-一对多 2-多对一 3-多对多')"`
+	OnDelete       int    `json:"onDelete,omitempty" xorm:"comment('删除行为 0-未设置 1-禁止删除 2-级联删除 3-置空')"`
+	Comment        string `json:"comment,omitempty" xorm:"comment('关联说明')"`
+}
+
+func init() {
+	SyncModels = append(SyncModels, TableRelation{})
+}
+
+type TableRelationRequest struct {
+	*TableRelation
+}
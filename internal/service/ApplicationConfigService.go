@@ -18,6 +18,12 @@ func (s *applicationConfigService) Add(instance *model.ApplicationConfig) (isDup
 	if instance.ApplicationId == "" {
 		return false, false, errors.New("应用ID不能为空")
 	}
+	if err = validateApplicationConfig(instance); err != nil {
+		return
+	}
+	if err = requireApplication(instance.ApplicationId); err != nil {
+		return
+	}
 	var c int64 = 0
 	c, err = database.DB.Count(&model.ApplicationConfig{
 		ApplicationId: instance.ApplicationId,
@@ -54,10 +60,25 @@ func (s *applicationConfigService) Update(instance *model.ApplicationConfig) (bo
 		return false, errors.New("ID不能为空")
 	}
 	// 不允许更改的字段
+	if err := validateApplicationConfig(instance); err != nil {
+		return false, err
+	}
+	if instance.ApplicationId != "" {
+		old := new(model.ApplicationConfig)
+		if has, err := database.DB.ID(instance.Id).Cols("application_id").Get(old); err != nil {
+			return false, err
+		} else if !has {
+			return false, nil
+		}
+		if old.ApplicationId != instance.ApplicationId {
+			return false, parentMismatch("应用配置不能移动到其他应用")
+		}
+	}
 
 	c, err := database.DB.ID(instance.Id).Update(&model.ApplicationConfig{
 		// 允许更改的字段
-		PageType: instance.PageType,
+		PageType:         instance.PageType,
+		TokenExpireHours: instance.TokenExpireHours,
 	})
 	if err != nil {
 		return false, err
@@ -122,3 +143,31 @@ func (s *applicationConfigService) PaginateBetweenTimes(condition *model.Applica
 	}
 	return int(total), list, nil
 }
+
+func validateApplicationConfig(instance *model.ApplicationConfig) error {
+	if instance.PageType&^model.PageTypeAll != 0 {
+		return errors.New("页面类型不正确")
+	}
+	if instance.TokenExpireHours < 0 {
+		return errors.New("token失效时长不能为负数")
+	}
+	return nil
+}
+
+// GetByApplication 获取应用的配置，未配置或未设置的项使用默认值
+func (s *applicationConfigService) GetByApplication(applicationId string) (*model.ApplicationConfig, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	config := &model.ApplicationConfig{ApplicationId: applicationId}
+	if _, err := database.DB.Get(config); err != nil {
+		return nil, err
+	}
+	if config.PageType == 0 {
+		config.PageType = model.DefaultPageType
+	}
+	if config.TokenExpireHours == 0 {
+		config.TokenExpireHours = model.DefaultTokenExpireHours
+	}
+	return config, nil
+}
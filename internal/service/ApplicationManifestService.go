@@ -0,0 +1,517 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var ApplicationManifestService = new(applicationManifestService)
+
+type applicationManifestService struct{}
+
+// Export 导出应用定义清单
+func (s *applicationManifestService) Export(applicationId string) (*model.ApplicationManifest, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	application := new(model.Application)
+	if exist, err := database.DB.ID(applicationId).Get(application); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+	manifest := &model.ApplicationManifest{
+		ManifestVersion: model.CurrentManifestVersion,
+		Application: &model.ManifestApplication{
+			AppName: application.AppName,
+			Package: application.Package,
+			AppDesc: application.AppDesc,
+		},
+		Tables: make([]*model.ManifestTable, 0),
+	}
+
+	config := &model.ApplicationConfig{ApplicationId: applicationId}
+	if has, err := database.DB.Get(config); err != nil {
+		return nil, err
+	} else if has {
+		manifest.Config = &model.ManifestConfig{
+			PageType:         config.PageType,
+			TokenExpireHours: config.TokenExpireHours,
+		}
+	}
+
+	tables, err := TableConfigService.ListWithColumns(applicationId)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		mt := &model.ManifestTable{
+			TableName:    table.TableName,
+			TableComment: table.TableComment,
+			RecordType:   table.RecordType,
+			Columns:      make([]*model.ManifestColumn, 0, len(table.Columns)),
+		}
+		for _, column := range table.Columns {
+			mt.Columns = append(mt.Columns, &model.ManifestColumn{
+				ColumnName:    column.ColumnName,
+				DisplayName:   column.DisplayName,
+				ColumnComment: column.ColumnComment,
+				DisplayType:   column.DisplayType,
+				ColumnType:    column.ColumnType,
+				UpdateType:    column.UpdateType,
+				UpdateAlone:   column.UpdateAlone,
+				ZeroValue:     column.ZeroValue,
+				UniqueCheck:   column.UniqueCheck,
+				StringType:    column.StringType,
+				StringSearch:  column.StringSearch,
+				EnumJson:      column.EnumJson,
+				ColumnLength:  column.ColumnLength,
+				DecimalLength: column.DecimalLength,
+			})
+		}
+		columnNames := make(map[string]string)
+		for _, column := range table.Columns {
+			columnNames[column.Id] = column.ColumnName
+		}
+		for _, index := range table.Indexes {
+			mi := &model.ManifestIndex{
+				IndexName: index.IndexName,
+				IsUnique:  index.IsUnique,
+			}
+			for _, ic := range index.Columns {
+				mi.Columns = append(mi.Columns, &model.ManifestIndexColumn{
+					ColumnName:   columnNames[ic.ColumnId],
+					PrefixLength: ic.PrefixLength,
+				})
+			}
+			mt.Indexes = append(mt.Indexes, mi)
+		}
+		manifest.Tables = append(manifest.Tables, mt)
+	}
+
+	relations, err := TableRelationService.ListByApplication(applicationId)
+	if err != nil {
+		return nil, err
+	}
+	tableNames := make(map[string]string)
+	columnNames := make(map[string]string)
+	for _, table := range tables {
+		tableNames[table.Id] = table.TableName
+		for _, column := range table.Columns {
+			columnNames[column.Id] = column.ColumnName
+		}
+	}
+	for _, relation := range relations {
+		manifest.Relations = append(manifest.Relations, &model.ManifestRelation{
+			RelationName: relation.RelationName,
+			SourceTable:  tableNames[relation.SourceTableId],
+			SourceColumn: columnNames[relation.SourceColumnId],
+			TargetTable:  tableNames[relation.TargetTableId],
+			Cardinality:  relation.Cardinality,
+			OnDelete:     relation.OnDelete,
+			Comment:      relation.Comment,
+		})
+	}
+
+	codeTemplates, err := CodeTemplateService.ListLatest(applicationId)
+	if err != nil {
+		return nil, err
+	}
+	for _, codeTemplate := range codeTemplates {
+		manifest.CodeTemplates = append(manifest.CodeTemplates, &model.ManifestTemplate{
+			Kind:    codeTemplate.Kind,
+			Content: codeTemplate.Content,
+		})
+	}
+	return manifest, nil
+}
+
+// Import 导入应用定义清单，create模式创建新应用，merge模式按表名及字段名合并到已有应用。
+// 存在冲突时不写入任何数据，冲突在结果中返回
+func (s *applicationManifestService) Import(manifest *model.ApplicationManifest, mode string, applicationId string, ownerId string) (*model.ManifestImportResult, error) {
+	if manifest == nil || manifest.Application == nil {
+		return nil, errors.New("清单内容不能为空")
+	}
+	if manifest.ManifestVersion > model.CurrentManifestVersion {
+		return nil, fmt.Errorf("不支持的清单版本%d", manifest.ManifestVersion)
+	}
+	result := &model.ManifestImportResult{Conflicts: validateManifest(manifest)}
+
+	var application *model.Application
+	switch mode {
+	case model.ManifestImportCreate, "":
+		// 与ApplicationService.Add的规则保持一致
+		if manifest.Application.AppName == "" || manifest.Application.Package == "" {
+			result.Conflicts = append(result.Conflicts, "应用名/包名必须提供")
+		} else if c, err := database.DB.Count(&model.Application{AppName: manifest.Application.AppName}); err != nil {
+			return nil, err
+		} else if c > 0 {
+			result.Conflicts = append(result.Conflicts, fmt.Sprintf("应用名%s已存在", manifest.Application.AppName))
+		}
+		if ownerId == "" {
+			result.Conflicts = append(result.Conflicts, "所属用户未能获取到")
+		}
+		application = &model.Application{
+			Id:      model.ApplicationIdPrefix + util.GenerateDatabaseID(),
+			AppName: manifest.Application.AppName,
+			Package: manifest.Application.Package,
+			AppDesc: manifest.Application.AppDesc,
+			OwnerId: ownerId,
+		}
+	case model.ManifestImportMerge:
+		if applicationId == "" {
+			return nil, errors.New("合并导入时应用ID不能为空")
+		}
+		application = new(model.Application)
+		if exist, err := database.DB.ID(applicationId).Get(application); err != nil {
+			return nil, err
+		} else if !exist {
+			return nil, errors.New("ID所指向的应用不存在")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的导入模式%s", mode)
+	}
+	if len(result.Conflicts) > 0 {
+		return result, nil
+	}
+
+	session := database.DB.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return nil, err
+	}
+	if err := s.importInto(session, application, manifest, mode != model.ManifestImportMerge, result); err != nil {
+		_ = session.Rollback()
+		return nil, err
+	}
+	if err := session.Commit(); err != nil {
+		return nil, err
+	}
+	result.Application = application
+	return result, nil
+}
+
+// validateManifest 检查清单内部的表名及字段名是否为空或重复
+func validateManifest(manifest *model.ApplicationManifest) []string {
+	var conflicts []string
+	if manifest.Config != nil {
+		if err := validateApplicationConfig(&model.ApplicationConfig{
+			PageType:         manifest.Config.PageType,
+			TokenExpireHours: manifest.Config.TokenExpireHours,
+		}); err != nil {
+			conflicts = append(conflicts, err.Error())
+		}
+	}
+	tableNames := make(map[string]bool)
+	for i, table := range manifest.Tables {
+		if table.TableName == "" {
+			conflicts = append(conflicts, fmt.Sprintf("第%d个表缺少表名", i+1))
+			continue
+		}
+		key := strings.ToLower(table.TableName)
+		if tableNames[key] {
+			conflicts = append(conflicts, fmt.Sprintf("表名%s重复", table.TableName))
+		}
+		tableNames[key] = true
+		columnNames := make(map[string]bool)
+		for j, column := range table.Columns {
+			if column.ColumnName == "" {
+				conflicts = append(conflicts, fmt.Sprintf("%s的第%d个字段缺少字段名", table.TableName, j+1))
+				continue
+			}
+			key := strings.ToLower(column.ColumnName)
+			if columnNames[key] {
+				conflicts = append(conflicts, fmt.Sprintf("%s.%s字段名重复", table.TableName, column.ColumnName))
+			}
+			columnNames[key] = true
+		}
+		indexNames := make(map[string]bool)
+		for j, index := range table.Indexes {
+			if index.IndexName == "" {
+				conflicts = append(conflicts, fmt.Sprintf("%s的第%d个索引缺少索引名", table.TableName, j+1))
+				continue
+			}
+			if indexNames[strings.ToLower(index.IndexName)] {
+				conflicts = append(conflicts, fmt.Sprintf("%s.%s索引名重复", table.TableName, index.IndexName))
+			}
+			indexNames[strings.ToLower(index.IndexName)] = true
+			if len(index.Columns) == 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s.%s索引没有任何字段", table.TableName, index.IndexName))
+			}
+			for _, ic := range index.Columns {
+				if !columnNames[strings.ToLower(ic.ColumnName)] {
+					conflicts = append(conflicts, fmt.Sprintf("%s.%s索引的字段%s不在清单中", table.TableName, index.IndexName, ic.ColumnName))
+				}
+			}
+		}
+	}
+	for i, relation := range manifest.Relations {
+		if relation.SourceTable == "" || relation.TargetTable == "" {
+			conflicts = append(conflicts, fmt.Sprintf("第%d个关联缺少源表或目标表", i+1))
+		}
+	}
+	for i, mt := range manifest.CodeTemplates {
+		if mt.Content == "" {
+			conflicts = append(conflicts, fmt.Sprintf("第%d个代码模板内容为空", i+1))
+		} else if err := CodeTemplateService.checkKind(mt.Kind); err != nil {
+			conflicts = append(conflicts, err.Error())
+		}
+	}
+	return conflicts
+}
+
+func (s *applicationManifestService) importInto(session *xorm.Session, application *model.Application, manifest *model.ApplicationManifest, isNew bool, result *model.ManifestImportResult) error {
+	if isNew {
+		if _, err := session.Insert(application); err != nil {
+			return err
+		}
+	}
+	if manifest.Config != nil {
+		config := &model.ApplicationConfig{ApplicationId: application.Id}
+		has, err := session.Get(config)
+		if err != nil {
+			return err
+		}
+		config.PageType = manifest.Config.PageType
+		config.TokenExpireHours = manifest.Config.TokenExpireHours
+		if has {
+			_, err = session.ID(config.Id).Cols("page_type", "token_expire_hours").Update(config)
+		} else {
+			config.Id = model.ApplicationConfigIdPrefix + util.GenerateDatabaseID()
+			_, err = session.Insert(config)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// 表、字段及索引名不区分大小写，与清单校验及迁移时的匹配方式一致
+	var tables []*model.TableConfig
+	if err := session.Where("application_id = ?", application.Id).Find(&tables); err != nil {
+		return err
+	}
+	// 清单中表及字段的顺序即为排序
+	for i, mt := range manifest.Tables {
+		table := findTableByName(tables, mt.TableName)
+		has := table != nil
+		if !has {
+			table = &model.TableConfig{ApplicationId: application.Id, TableName: mt.TableName}
+		}
+		table.TableComment = mt.TableComment
+		table.RecordType = mt.RecordType
+		table.SortOrder = i + 1
+		applyTableDefaults(table)
+		if has {
+			if _, err := session.ID(table.Id).Cols("table_comment", "record_type", "sort_order").Update(table); err != nil {
+				return err
+			}
+			result.TablesUpdated++
+		} else {
+			table.Id = model.TableConfigIdPrefix + util.GenerateDatabaseID()
+			if _, err := session.Insert(table); err != nil {
+				return err
+			}
+			tables = append(tables, table)
+			result.TablesCreated++
+		}
+
+		var columns []*model.ColumnConfig
+		if err := session.Where("table_id = ?", table.Id).Find(&columns); err != nil {
+			return err
+		}
+		for j, mc := range mt.Columns {
+			column := columnFromManifest(mc)
+			column.ApplicationId = application.Id
+			column.TableId = table.Id
+			column.SortOrder = j + 1
+			applyColumnDefaults(column)
+			if old := findColumnByName(columns, mc.ColumnName); old != nil {
+				column.Id = old.Id
+				if _, err := session.ID(old.Id).AllCols().Update(column); err != nil {
+					return err
+				}
+				result.ColumnsUpdated++
+			} else {
+				column.Id = model.ColumnConfigIdPrefix + util.GenerateDatabaseID()
+				if _, err := session.Insert(column); err != nil {
+					return err
+				}
+				columns = append(columns, column)
+				result.ColumnsCreated++
+			}
+		}
+
+		var indexes []*model.TableIndex
+		if err := session.Where("table_id = ?", table.Id).Find(&indexes); err != nil {
+			return err
+		}
+		for _, mi := range mt.Indexes {
+			if findIndexByName(indexes, mi.IndexName) != nil {
+				continue
+			}
+			index := &model.TableIndex{
+				Id:            model.TableIndexIdPrefix + util.GenerateDatabaseID(),
+				ApplicationId: application.Id,
+				TableId:       table.Id,
+				IndexName:     mi.IndexName,
+				IsUnique:      mi.IsUnique,
+			}
+			for _, mic := range mi.Columns {
+				column := findColumnByName(columns, mic.ColumnName)
+				if column == nil {
+					return fmt.Errorf("索引字段%s.%s不存在", mt.TableName, mic.ColumnName)
+				}
+				index.Columns = append(index.Columns, &model.IndexColumn{ColumnId: column.Id, PrefixLength: mic.PrefixLength})
+			}
+			if _, err := session.Insert(index); err != nil {
+				return err
+			}
+			indexes = append(indexes, index)
+			result.IndexesAdded++
+		}
+	}
+
+	for _, mr := range manifest.Relations {
+		relation, err := resolveManifestRelation(session, application.Id, mr)
+		if err != nil {
+			return err
+		}
+		has, err := session.Exist(&model.TableRelation{
+			SourceTableId:  relation.SourceTableId,
+			SourceColumnId: relation.SourceColumnId,
+			TargetTableId:  relation.TargetTableId,
+		})
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		relation.Id = model.TableRelationIdPrefix + util.GenerateDatabaseID()
+		if _, err = session.Insert(relation); err != nil {
+			return err
+		}
+		result.RelationsAdded++
+	}
+
+	// 与最新版本内容不同时作为新版本加入
+	for _, mt := range manifest.CodeTemplates {
+		latest := new(model.CodeTemplate)
+		has, err := session.Where("application_id = ? and kind = ?", application.Id, mt.Kind).Desc("version").Get(latest)
+		if err != nil {
+			return err
+		}
+		if has && latest.Content == mt.Content {
+			continue
+		}
+		codeTemplate := &model.CodeTemplate{
+			Id:            model.CodeTemplateIdPrefix + util.GenerateDatabaseID(),
+			ApplicationId: application.Id,
+			Kind:          mt.Kind,
+			Version:       latest.Version + 1,
+			Content:       mt.Content,
+			OwnerId:       application.OwnerId,
+		}
+		if _, err = session.Insert(codeTemplate); err != nil {
+			return err
+		}
+		result.TemplatesAdded++
+	}
+	return nil
+}
+
+// resolveManifestRelation 将清单中以名称引用的关联转换为以ID引用
+func resolveManifestRelation(session *xorm.Session, applicationId string, mr *model.ManifestRelation) (*model.TableRelation, error) {
+	relation := &model.TableRelation{
+		ApplicationId: applicationId,
+		RelationName:  mr.RelationName,
+		Cardinality:   mr.Cardinality,
+		OnDelete:      mr.OnDelete,
+		Comment:       mr.Comment,
+	}
+	var tables []*model.TableConfig
+	if err := session.Where("application_id = ?", applicationId).Find(&tables); err != nil {
+		return nil, err
+	}
+	source := findTableByName(tables, mr.SourceTable)
+	if source == nil {
+		return nil, fmt.Errorf("关联的源表%s不存在", mr.SourceTable)
+	}
+	target := findTableByName(tables, mr.TargetTable)
+	if target == nil {
+		return nil, fmt.Errorf("关联的目标表%s不存在", mr.TargetTable)
+	}
+	relation.SourceTableId = source.Id
+	relation.TargetTableId = target.Id
+	if mr.SourceColumn != "" {
+		var columns []*model.ColumnConfig
+		if err := session.Where("table_id = ?", source.Id).Find(&columns); err != nil {
+			return nil, err
+		}
+		column := findColumnByName(columns, mr.SourceColumn)
+		if column == nil {
+			return nil, fmt.Errorf("关联字段%s.%s不存在", mr.SourceTable, mr.SourceColumn)
+		}
+		relation.SourceColumnId = column.Id
+	}
+	if relation.OnDelete == 0 {
+		relation.OnDelete = model.OnDeleteRestrict
+	}
+	return relation, nil
+}
+
+// findTableByName 按表名查找表，不区分大小写
+func findTableByName(tables []*model.TableConfig, name string) *model.TableConfig {
+	for _, table := range tables {
+		if strings.EqualFold(table.TableName, name) {
+			return table
+		}
+	}
+	return nil
+}
+
+// findIndexByName 按索引名查找索引，不区分大小写
+func findIndexByName(indexes []*model.TableIndex, name string) *model.TableIndex {
+	for _, index := range indexes {
+		if strings.EqualFold(index.IndexName, name) {
+			return index
+		}
+	}
+	return nil
+}
+
+// findColumnByName 按字段名查找字段，不区分大小写
+func findColumnByName(columns []*model.ColumnConfig, name string) *model.ColumnConfig {
+	for _, column := range columns {
+		if strings.EqualFold(column.ColumnName, name) {
+			return column
+		}
+	}
+	return nil
+}
+
+func columnFromManifest(mc *model.ManifestColumn) *model.ColumnConfig {
+	return &model.ColumnConfig{
+		ColumnName:    mc.ColumnName,
+		DisplayName:   mc.DisplayName,
+		ColumnComment: mc.ColumnComment,
+		DisplayType:   mc.DisplayType,
+		ColumnType:    mc.ColumnType,
+		UpdateType:    mc.UpdateType,
+		UpdateAlone:   mc.UpdateAlone,
+		ZeroValue:     mc.ZeroValue,
+		UniqueCheck:   mc.UniqueCheck,
+		StringType:    mc.StringType,
+		StringSearch:  mc.StringSearch,
+		EnumJson:      mc.EnumJson,
+		ColumnLength:  mc.ColumnLength,
+		DecimalLength: mc.DecimalLength,
+	}
+}
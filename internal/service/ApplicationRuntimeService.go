@@ -0,0 +1,452 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/logger"
+	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+
+	"github.com/yockii/quick-system/internal/model"
+	qsUtil "github.com/yockii/quick-system/internal/util"
+)
+
+var ApplicationRuntimeService = &applicationRuntimeService{apps: make(map[string]*runtimeApp)}
+
+// applicationRuntimeService 管理应用的运行时部署，运行中的应用按路由前缀注册在apps中
+type applicationRuntimeService struct {
+	mu   sync.RWMutex
+	apps map[string]*runtimeApp
+}
+
+// runtimeDialect 运行时建表使用系统数据库，按驱动名确定DDL语法
+func runtimeDialect() (sqlDialect, error) {
+	switch database.DB.DriverName() {
+	case "mysql":
+		return mysqlDialect{}, nil
+	case "postgres", "pgx":
+		return postgresDialect{}, nil
+	}
+	return nil, fmt.Errorf("运行时模式不支持数据库%s", database.DB.DriverName())
+}
+
+// runtimeTables 将表设计的表名及索引名加上物理表名前缀，不修改原设计
+func runtimeTables(tablePrefix string, tables []*model.TableSnapshot) []*model.TableSnapshot {
+	result := make([]*model.TableSnapshot, 0, len(tables))
+	for _, table := range tables {
+		tc := *table.TableConfig
+		tc.TableName = tablePrefix + table.TableName
+		t := &model.TableSnapshot{TableConfig: &tc, Columns: table.Columns}
+		for _, index := range table.Indexes {
+			ti := *index
+			ti.IndexName = tablePrefix + index.IndexName
+			t.Indexes = append(t.Indexes, &ti)
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// Deploy 按当前表设计创建或变更物理表，并以新设计提供运行时接口。
+// 设计校验有错误时不部署；变更存在破坏性操作且未指定Force时只返回迁移脚本
+func (s *applicationRuntimeService) Deploy(req *model.RuntimeDeployRequest, ownerId string) (*model.RuntimeDeployResult, *model.LintResult, error) {
+	if req.ApplicationId == "" {
+		return nil, nil, errors.New("应用ID不能为空")
+	}
+	d, err := runtimeDialect()
+	if err != nil {
+		return nil, nil, err
+	}
+	application := new(model.Application)
+	if exist, err := database.DB.ID(req.ApplicationId).Get(application); err != nil {
+		return nil, nil, err
+	} else if !exist {
+		return nil, nil, errors.New("ID所指向的应用不存在")
+	}
+	tables, err := TableConfigService.ListWithColumns(req.ApplicationId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tables) == 0 {
+		return nil, nil, errors.New("应用没有任何表")
+	}
+	if lint := lintTables(tables); lint.Errors > 0 {
+		return nil, lint, nil
+	}
+
+	runtime := &model.ApplicationRuntime{ApplicationId: req.ApplicationId}
+	isNew := true
+	if has, err := database.DB.Get(runtime); err != nil {
+		return nil, nil, err
+	} else if has {
+		isNew = false
+	}
+	routePrefix := req.RoutePrefix
+	if routePrefix == "" {
+		routePrefix = runtime.RoutePrefix
+	}
+	if routePrefix == "" {
+		routePrefix = application.Package
+	}
+	if !identifierPattern.MatchString(routePrefix) {
+		return nil, nil, fmt.Errorf("路由前缀%s只能包含字母、数字及下划线，且不能以数字开头", routePrefix)
+	}
+	if routePrefix != runtime.RoutePrefix {
+		// 路由不区分大小写，前缀只差大小写时同样冲突
+		c, err := database.DB.Where("application_id <> ? and lower(route_prefix) = ?", req.ApplicationId, strings.ToLower(routePrefix)).
+			Count(&model.ApplicationRuntime{})
+		if err != nil {
+			return nil, nil, err
+		}
+		if c > 0 {
+			return nil, nil, fmt.Errorf("路由前缀%s已被其他应用使用", routePrefix)
+		}
+	}
+	if isNew {
+		runtime.TablePrefix = runtimeTablePrefix(req.ApplicationId)
+		c, err := database.DB.Where("application_id <> ?", req.ApplicationId).Count(&model.ApplicationRuntime{TablePrefix: runtime.TablePrefix})
+		if err != nil {
+			return nil, nil, err
+		}
+		if c > 0 {
+			return nil, nil, fmt.Errorf("物理表名前缀%s已被其他应用使用", runtime.TablePrefix)
+		}
+	}
+
+	var from []*model.TableSnapshot
+	if runtime.Snapshot != nil {
+		from = runtimeTables(runtime.TablePrefix, runtime.Snapshot.Tables)
+	}
+	script := diffSchema(d, from, runtimeTables(runtime.TablePrefix, tables))
+	script.Dialect = database.DB.DriverName()
+	result := &model.RuntimeDeployResult{Script: script}
+	if script.Destructive > 0 && !req.Force {
+		return result, nil, nil
+	}
+
+	app, err := newRuntimeApp(req.ApplicationId, runtime.TablePrefix, d, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+	// MySQL的DDL会隐式提交，上次部署中途失败时已执行的语句不会回滚而快照仍是旧设计，
+	// 因此按数据库的实际结构跳过已经生效的步骤，使重新部署可以继续
+	metas, err := database.DB.DBMetas()
+	if err != nil {
+		return nil, nil, err
+	}
+	steps := pendingRuntimeSteps(script.Steps, metas)
+	_, err = database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		for _, step := range steps {
+			for _, statement := range step.Statements {
+				if _, err := session.Exec(statement); err != nil {
+					return nil, err
+				}
+			}
+		}
+		runtime.RoutePrefix = routePrefix
+		runtime.Status = model.RuntimeStatusRunning
+		runtime.Snapshot = &model.ApplicationSnapshot{Tables: tables}
+		runtime.DeployTime = domain.DateTime(time.Now())
+		if isNew {
+			runtime.Id = model.ApplicationRuntimeIdPrefix + util.GenerateDatabaseID()
+			runtime.OwnerId = ownerId
+			_, err := session.Insert(runtime)
+			return nil, err
+		}
+		_, err := session.ID(runtime.Id).Cols("route_prefix", "status", "snapshot", "deploy_time").Update(runtime)
+		return nil, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	for prefix, old := range s.apps {
+		if old.applicationId == req.ApplicationId {
+			delete(s.apps, prefix)
+		}
+	}
+	s.apps[routePrefix] = app
+	s.mu.Unlock()
+
+	result.Runtime = runtime
+	result.Applied = true
+	return result, nil, nil
+}
+
+// Stop 停止提供运行时接口，物理表及数据保留
+func (s *applicationRuntimeService) Stop(applicationId string) (bool, error) {
+	if applicationId == "" {
+		return false, errors.New("应用ID不能为空")
+	}
+	c, err := database.DB.Where("application_id = ?", applicationId).Cols("status").Update(&model.ApplicationRuntime{Status: model.RuntimeStatusStopped})
+	if err != nil {
+		return false, err
+	}
+	s.unregister(applicationId)
+	return c > 0, nil
+}
+
+// Start 以最近一次部署的设计重新提供运行时接口
+func (s *applicationRuntimeService) Start(applicationId string) (bool, error) {
+	if applicationId == "" {
+		return false, errors.New("应用ID不能为空")
+	}
+	runtime := &model.ApplicationRuntime{ApplicationId: applicationId}
+	if has, err := database.DB.Get(runtime); err != nil {
+		return false, err
+	} else if !has {
+		return false, nil
+	}
+	if err := s.register(runtime); err != nil {
+		return false, err
+	}
+	if _, err := database.DB.ID(runtime.Id).Cols("status").Update(&model.ApplicationRuntime{Status: model.RuntimeStatusRunning}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove 删除运行时部署，dropTables为true时同时删除物理表及数据
+func (s *applicationRuntimeService) Remove(applicationId string, dropTables bool) (bool, error) {
+	if applicationId == "" {
+		return false, errors.New("应用ID不能为空")
+	}
+	runtime := &model.ApplicationRuntime{ApplicationId: applicationId}
+	if has, err := database.DB.Get(runtime); err != nil {
+		return false, err
+	} else if !has {
+		return false, nil
+	}
+	if _, err := database.DB.ID(runtime.Id).Delete(&model.ApplicationRuntime{}); err != nil {
+		return false, err
+	}
+	// 记录删除成功后才停止提供接口；删除表的DDL会隐式提交，放在最后单独执行
+	s.unregister(applicationId)
+	if dropTables {
+		if _, err := s.dropTables(runtime); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// runtimeTablePrefix 物理表名前缀由应用ID确定，与路由前缀无关，修改路由前缀后其他应用也无法使用到该应用的表。
+// 取应用ID摘要的前10位十六进制，不含下划线，前缀与表名的拼接不会产生歧义，且加上50位的表名不超过64位的表名长度限制
+func runtimeTablePrefix(applicationId string) string {
+	sum := sha1.Sum([]byte(applicationId))
+	return "rt_" + hex.EncodeToString(sum[:])[:10] + "_"
+}
+
+// pendingRuntimeSteps 过滤掉数据库中已经生效的迁移步骤，表、字段及索引名不区分大小写
+func pendingRuntimeSteps(steps []*model.MigrationStep, metas []*schemas.Table) []*model.MigrationStep {
+	tables := make(map[string]*schemas.Table)
+	for _, meta := range metas {
+		tables[strings.ToLower(meta.Name)] = meta
+	}
+	hasColumn := func(table *schemas.Table, name string) bool {
+		for _, column := range table.Columns() {
+			if strings.EqualFold(column.Name, name) {
+				return true
+			}
+		}
+		return false
+	}
+	hasIndex := func(table *schemas.Table, name string) bool {
+		for indexName := range table.Indexes {
+			if strings.EqualFold(indexName, name) {
+				return true
+			}
+		}
+		return false
+	}
+	pending := make([]*model.MigrationStep, 0, len(steps))
+	for _, step := range steps {
+		table := tables[strings.ToLower(step.Table)]
+		var applied bool
+		switch step.Action {
+		case model.MigrationCreateTable, model.MigrationRenameTable:
+			applied = table != nil
+		case model.MigrationDropTable:
+			applied = table == nil
+		case model.MigrationAddColumn:
+			applied = table != nil && hasColumn(table, step.Column)
+		case model.MigrationDropColumn:
+			applied = table == nil || !hasColumn(table, step.Column)
+		case model.MigrationCreateIndex:
+			applied = table != nil && hasIndex(table, step.Index)
+		case model.MigrationDropIndex:
+			applied = table == nil || !hasIndex(table, step.Index)
+		}
+		if !applied {
+			pending = append(pending, step)
+		}
+	}
+	return pending
+}
+
+func (s *applicationRuntimeService) dropTables(runtime *model.ApplicationRuntime) ([]string, error) {
+	if runtime.Snapshot == nil {
+		return nil, nil
+	}
+	d, err := runtimeDialect()
+	if err != nil {
+		return nil, err
+	}
+	var dropped []string
+	for _, table := range runtime.Snapshot.Tables {
+		name := runtime.TablePrefix + table.TableName
+		if _, err := database.DB.Exec("DROP TABLE IF EXISTS " + d.quote(name)); err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, name)
+	}
+	return dropped, nil
+}
+
+func (s *applicationRuntimeService) Get(instance *model.ApplicationRuntime) (*model.ApplicationRuntime, error) {
+	if instance.Id == "" && instance.ApplicationId == "" {
+		return nil, errors.New("ID或应用ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+func (s *applicationRuntimeService) Paginate(condition *model.ApplicationRuntime, limit, offset int, orderBy string) (int, []*model.ApplicationRuntime, error) {
+	return s.PaginateBetweenTimes(condition, limit, offset, orderBy, nil)
+}
+
+// PaginateBetweenTimes 分页列出运行时部署，不包含设计快照
+func (s *applicationRuntimeService) PaginateBetweenTimes(condition *model.ApplicationRuntime, limit, offset int, orderBy string, tcList map[string]*domain.TimeCondition) (int, []*model.ApplicationRuntime, error) {
+	// 处理不允许查询的字段
+	condition.Snapshot = nil
+
+	// 处理sql
+	session := database.DB.NewSession()
+	session.Omit("snapshot")
+	if limit > -1 && offset > -1 {
+		session.Limit(limit, offset)
+	}
+
+	if orderBy != "" {
+		session.OrderBy(orderBy)
+	}
+	session.Desc("deploy_time")
+
+	// 处理时间字段，在某段时间之间
+	for tc, tr := range tcList {
+		if tc != "" {
+			if !tr.Start.IsZero() && !tr.End.IsZero() {
+				session.Where(tc+" between ? and ?", tr.Start, tr.End)
+			} else if tr.Start.IsZero() {
+				session.Where(tc+" <= ?", tr.End)
+			} else if tr.End.IsZero() {
+				session.Where(tc+" > ?", tr.Start)
+			}
+		}
+	}
+
+	var list []*model.ApplicationRuntime
+	total, err := session.FindAndCount(&list, condition)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(total), list, nil
+}
+
+// LoadAll 服务启动时注册全部运行中的应用
+func (s *applicationRuntimeService) LoadAll() error {
+	var list []*model.ApplicationRuntime
+	if err := database.DB.Find(&list, &model.ApplicationRuntime{Status: model.RuntimeStatusRunning}); err != nil {
+		return err
+	}
+	for _, runtime := range list {
+		if err := s.register(runtime); err != nil {
+			logger.Error(fmt.Errorf("运行时应用%s加载失败: %s", runtime.RoutePrefix, err.Error()))
+		}
+	}
+	return nil
+}
+
+func (s *applicationRuntimeService) register(runtime *model.ApplicationRuntime) error {
+	if runtime.Snapshot == nil {
+		return errors.New("运行时没有部署记录")
+	}
+	d, err := runtimeDialect()
+	if err != nil {
+		return err
+	}
+	app, err := newRuntimeApp(runtime.ApplicationId, runtime.TablePrefix, d, runtime.Snapshot.Tables)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.apps[runtime.RoutePrefix] = app
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *applicationRuntimeService) unregister(applicationId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for prefix, app := range s.apps {
+		if app.applicationId == applicationId {
+			delete(s.apps, prefix)
+		}
+	}
+}
+
+// registeredApplicationIds 已注册运行时接口的应用ID
+func (s *applicationRuntimeService) registeredApplicationIds() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.apps))
+	for _, app := range s.apps {
+		ids = append(ids, app.applicationId)
+	}
+	return ids
+}
+
+// table 按路由前缀及表路由名(表名小驼峰)查找运行中的表
+func (s *applicationRuntimeService) table(routePrefix, tableName string) *runtimeTable {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	app, ok := s.apps[routePrefix]
+	if !ok {
+		return nil
+	}
+	return app.tables[tableName]
+}
+
+// runtimeApp 运行中的应用
+type runtimeApp struct {
+	applicationId string
+	tables        map[string]*runtimeTable
+}
+
+func newRuntimeApp(applicationId, tablePrefix string, d sqlDialect, tables []*model.TableSnapshot) (*runtimeApp, error) {
+	app := &runtimeApp{applicationId: applicationId, tables: make(map[string]*runtimeTable)}
+	for _, table := range tables {
+		rt, err := newRuntimeTable(tablePrefix, d, table)
+		if err != nil {
+			return nil, err
+		}
+		app.tables[qsUtil.CamelCase(table.TableName)] = rt
+	}
+	return app, nil
+}
@@ -1,13 +1,16 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"strings"
 
 	"github.com/yockii/qscore/pkg/database"
 	"github.com/yockii/qscore/pkg/domain"
 	"github.com/yockii/qscore/pkg/logger"
 	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
 
 	gDomain "github.com/yockii/qs-code-generator/pkg/domain"
 	"github.com/yockii/qs-code-generator/pkg/generator"
@@ -35,23 +38,112 @@ func (s *applicationService) Add(instance *model.Application) (isDuplicated bool
 		return
 	}
 	instance.Id = model.ApplicationIdPrefix + util.GenerateDatabaseID()
-	_, err = database.DB.Insert(instance)
+	if instance.TemplateId == "" {
+		_, err = database.DB.Insert(instance)
+		success = err == nil
+		return
+	}
+
+	// 从模板创建，应用与模板中的表及字段在同一事务中写入
+	template, err := ApplicationTemplateService.Get(&model.ApplicationTemplate{Id: instance.TemplateId})
+	if err != nil {
+		return false, false, err
+	}
+	if template == nil || template.Manifest == nil {
+		return false, false, errors.New("模板不存在")
+	}
+	if conflicts := validateManifest(template.Manifest); len(conflicts) > 0 {
+		return false, false, errors.New("模板内容不正确: " + conflicts[0])
+	}
+	_, err = database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		return nil, ApplicationManifestService.importInto(session, instance, template.Manifest, true, new(model.ManifestImportResult))
+	})
 	success = err == nil
 	return
 }
 
-func (s *applicationService) Remove(instance *model.Application) (bool, error) {
+// Remove 删除应用及其配置、表、字段、索引、表关联、代码模板、生成任务、源码包及运行时部署，
+// dryRun为true时仅统计将删除的记录数，dropRuntimeTables为true时同时删除运行时的物理表。应用不存在时返回nil
+func (s *applicationService) Remove(instance *model.Application, dryRun bool, dropRuntimeTables bool) (*model.CascadeDeleteResult, error) {
 	if instance.Id == "" {
-		return false, errors.New("id不能为空")
+		return nil, errors.New("id不能为空")
 	}
-	c, err := database.DB.Delete(instance)
+	if exist, err := database.DB.Exist(&model.Application{Id: instance.Id}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, nil
+	}
+	plan := new(cascadePlan)
+	// 子记录在删除事务中按应用及所属表匹配，兼容未记录应用ID的旧数据
+	tables := "SELECT id FROM " + database.DB.TableName(&model.TableConfig{}, true) + " WHERE application_id = ?"
+	plan.addWhere("table_relation", &model.TableRelation{},
+		"application_id = ? OR source_table_id IN ("+tables+") OR target_table_id IN ("+tables+")",
+		instance.Id, instance.Id, instance.Id)
+	plan.addWhere("table_index", &model.TableIndex{}, "application_id = ? OR table_id IN ("+tables+")", instance.Id, instance.Id)
+	plan.addWhere("column_config", &model.ColumnConfig{}, "application_id = ? OR table_id IN ("+tables+")", instance.Id, instance.Id)
+	for _, child := range []struct {
+		name string
+		bean interface{}
+	}{
+		{"table_config", &model.TableConfig{}},
+		{"application_config", &model.ApplicationConfig{}},
+		{"code_template", &model.CodeTemplate{}},
+		{"generation_job", &model.GenerationJob{}},
+		{"application_source", &model.ApplicationSource{}},
+		{"application_runtime", &model.ApplicationRuntime{}},
+	} {
+		plan.addWhere(child.name, child.bean, "application_id = ?", instance.Id)
+	}
+	plan.addWhere("application", &model.Application{}, "id = ?", instance.Id)
+
+	runtime := &model.ApplicationRuntime{ApplicationId: instance.Id}
+	hasRuntime, err := database.DB.Get(runtime)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	if c == 0 {
-		return false, nil
+	if dryRun {
+		result, err := plan.preview()
+		if err != nil {
+			return nil, err
+		}
+		if hasRuntime && dropRuntimeTables && runtime.Snapshot != nil {
+			for _, table := range runtime.Snapshot.Tables {
+				result.DroppedTables = append(result.DroppedTables, runtime.TablePrefix+table.TableName)
+			}
+		}
+		return result, nil
 	}
-	return true, nil
+
+	// 先取消进行中的生成任务并等待执行中的任务结束，避免删除后任务又写入源码包
+	var jobs []*model.GenerationJob
+	if err = database.DB.Cols("id").Where("application_id = ?", instance.Id).
+		In("status", model.GenerationJobStatusQueued, model.GenerationJobStatusRunning).Find(&jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if _, err = GenerationJobService.Cancel(job.Id); err != nil {
+			return nil, err
+		}
+	}
+	unlock := GenerationJobService.lockApplication(instance.Id)
+	defer unlock()
+	result, err := plan.execute()
+	if err != nil {
+		return nil, err
+	}
+	if hasRuntime {
+		ApplicationRuntimeService.unregister(instance.Id)
+		// 删除表的DDL会隐式提交事务，因此在记录删除之后单独执行
+		if dropRuntimeTables {
+			dropped, err := ApplicationRuntimeService.dropTables(runtime)
+			result.DroppedTables = dropped
+			if err != nil {
+				logger.Error(err)
+				result.Warnings = append(result.Warnings, "运行时数据表删除失败: "+err.Error())
+			}
+		}
+	}
+	return result, nil
 }
 
 func (s *applicationService) Update(instance *model.Application) (bool, error) {
@@ -137,22 +229,79 @@ func (s *applicationService) PaginateBetweenTimes(condition *model.Application,
 	return int(total), list, nil
 }
 
-func (s *applicationService) GenerateCode(id string) (bool, error) {
+// GenerateCode 生成应用代码并校验，返回尚未分配版本号的源码包，由调用方在事务中入库
+func (s *applicationService) GenerateCode(ctx context.Context, id string, releaseNote string) (*model.ApplicationSource, error) {
 	if id == "" {
-		return false, errors.New("ID不能为空")
+		return nil, errors.New("ID不能为空")
+	}
+	app, snapshot, err := s.buildGeneratorApplication(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	bs, err := generator.GenerateApplicationSource(app)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) == 0 {
+		return nil, errors.New("代码生成结果为空")
 	}
+	logger.Debug("代码生成成功!")
+	// 生成器调用本身无法中断，只能在各阶段之间检查任务是否已被取消
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	// 校验生成的代码，未通过的版本仍然入库以便排查，但标记为损坏
+	checkResult, err := SourceCheckService.Check(bs)
+	if err != nil {
+		return nil, err
+	}
+	// 校验期间任务可能已被取消
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &model.ApplicationSource{
+		Id:            model.ApplicationSourceIdPrefix + util.GenerateDatabaseID(),
+		ApplicationId: id,
+		ReleaseNote:   releaseNote,
+		Snapshot:      snapshot,
+		Source:        bs,
+		Size:          int64(len(bs)),
+		Broken:        checkResult.Errors > 0,
+		CheckResult:   checkResult,
+	}, nil
+}
+
+// buildGeneratorApplication 读取应用的设计并转换为生成器的输入，同时返回生成时使用的设计快照
+func (s *applicationService) buildGeneratorApplication(id string) (*gDomain.Application, *model.ApplicationSnapshot, error) {
 	application := new(model.Application)
 	if exist, err := database.DB.ID(id).Get(application); err != nil {
-		return false, err
+		return nil, nil, err
 	} else if !exist {
-		return false, errors.New("ID所指向的应用不存在")
+		return nil, nil, errors.New("ID所指向的应用不存在")
+	}
+	config, err := ApplicationConfigService.GetByApplication(id)
+	if err != nil {
+		return nil, nil, err
 	}
 	app := new(gDomain.Application)
 	app.Package = application.Package
-	var tables []*model.TableConfig
-	if err := database.DB.Find(&tables, &model.TableConfig{ApplicationId: id}); err != nil {
-		return false, err
+	tables, err := TableConfigService.ListWithColumns(id)
+	if err != nil {
+		return nil, nil, err
 	}
+	relations, err := TableRelationService.ListByApplication(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	codeTemplates, err := CodeTemplateService.ListLatest(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 当前依赖的生成器不支持替换模板，自定义模板只记录在设计快照中，可通过预览接口渲染
+	snapshot := &model.ApplicationSnapshot{Config: config, Tables: tables, Relations: relations, CodeTemplates: codeTemplates}
 	var gtables []*gDomain.Table
 	for _, table := range tables {
 		gtable := &gDomain.Table{
@@ -163,36 +312,20 @@ func (s *applicationService) GenerateCode(id string) (bool, error) {
 		}
 		uniqueCheckColumns := make(map[int][]string)
 		var cs []*gDomain.Column
-		var columns []*model.ColumnConfig
-		if err := database.DB.Find(&columns, &model.ColumnConfig{TableId: table.Id}); err != nil {
-			return false, err
-		}
-		for _, column := range columns {
+		for _, column := range table.Columns {
 			c := &gDomain.Column{
 				Name:        column.ColumnName,
 				DisplayName: column.DisplayName,
 				Type:        column.ColumnType,
 				Comment:     column.ColumnComment,
-				Nullable:    column.ZeroValue != "!NIL",
-				Updatable:   column.UpdateType&2 == 2,
-				Searchable:  column.UpdateType&4 == 4,
+				Nullable:    column.ZeroValue != model.ZeroValueNotNull,
+				Updatable:   column.UpdateType&model.UpdateTypeUpdate == model.UpdateTypeUpdate,
+				Searchable:  column.UpdateType&model.UpdateTypeSearch == model.UpdateTypeSearch,
 			}
 			if c.Type == 0 {
-				c.Type = 1
-			}
-			if c.Type == 1 {
-				if column.StringType == 2 {
-					c.ColumnType = "longtext"
-				} else if column.ColumnLength > 0 {
-					c.ColumnType = fmt.Sprintf("varchar(%d)", column.ColumnLength)
-				}
-			} else if c.Type == 2 {
-				if column.DecimalLength > 0 {
-					c.ColumnType = fmt.Sprintf("decimal(%d,%d)", column.ColumnLength, column.DecimalLength)
-				} else if column.ColumnLength > 0 {
-					c.ColumnType = fmt.Sprintf("int(%d)", column.ColumnLength)
-				}
+				c.Type = model.ColumnTypeString
 			}
+			c.ColumnType = columnSqlType(column)
 			cs = append(cs, c)
 			if column.UniqueCheck > 0 {
 				_, ok := uniqueCheckColumns[column.UniqueCheck]
@@ -210,22 +343,162 @@ func (s *applicationService) GenerateCode(id string) (bool, error) {
 		gtables = append(gtables, gtable)
 	}
 	app.Tables = gtables
+	return app, snapshot, nil
+}
 
-	bs, err := generator.GenerateApplicationSource(app)
+// Clone 在同一事务中复制应用及其配置、表、字段、索引、表关联及自定义代码模板，子记录使用新ID并指向新的应用及表
+func (s *applicationService) Clone(req *model.ApplicationCloneRequest, ownerId string) (isDuplicated bool, instance *model.Application, err error) {
+	if req.Id == "" {
+		return false, nil, errors.New("ID不能为空")
+	}
+	if req.AppName == "" || req.Package == "" {
+		return false, nil, errors.New("应用名/包名不能为空")
+	}
+	source := new(model.Application)
+	if exist, err := database.DB.ID(req.Id).Get(source); err != nil {
+		return false, nil, err
+	} else if !exist {
+		return false, nil, errors.New("ID所指向的应用不存在")
+	}
+	var c int64
+	c, err = database.DB.Count(&model.Application{AppName: req.AppName})
 	if err != nil {
-		return false, err
+		return
 	}
-	if bs != nil {
-		logger.Debug("代码生成成功!")
-		// 入库
-		_, err = database.DB.Insert(&model.ApplicationSource{
-			Id:            util.GenerateDatabaseID(),
-			ApplicationId: id,
-			Source:        bs,
-		})
-		if err != nil {
-			logger.Error(err)
+	if c > 0 {
+		isDuplicated = true
+		return
+	}
+
+	instance = &model.Application{
+		Id:      model.ApplicationIdPrefix + util.GenerateDatabaseID(),
+		AppName: req.AppName,
+		Package: req.Package,
+		AppDesc: req.AppDesc,
+		OwnerId: ownerId,
+	}
+	if instance.AppDesc == "" {
+		instance.AppDesc = source.AppDesc
+	}
+	if instance.OwnerId == "" {
+		instance.OwnerId = source.OwnerId
+	}
+
+	_, err = database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		if _, err := session.Insert(instance); err != nil {
+			return nil, err
+		}
+		var configs []*model.ApplicationConfig
+		if err := session.Find(&configs, &model.ApplicationConfig{ApplicationId: source.Id}); err != nil {
+			return nil, err
+		}
+		for _, config := range configs {
+			config.Id = model.ApplicationConfigIdPrefix + util.GenerateDatabaseID()
+			config.ApplicationId = instance.Id
+			if _, err := session.Insert(config); err != nil {
+				return nil, err
+			}
+		}
+
+		// 记录新旧ID的对应关系，用于重新指向关联
+		idMap := make(map[string]string)
+		var tables []*model.TableConfig
+		if err := session.Find(&tables, &model.TableConfig{ApplicationId: source.Id}); err != nil {
+			return nil, err
 		}
+		for _, table := range tables {
+			var columns []*model.ColumnConfig
+			if err := session.Find(&columns, &model.ColumnConfig{TableId: table.Id}); err != nil {
+				return nil, err
+			}
+			var indexes []*model.TableIndex
+			if err := session.Find(&indexes, &model.TableIndex{TableId: table.Id}); err != nil {
+				return nil, err
+			}
+			newTableId := model.TableConfigIdPrefix + util.GenerateDatabaseID()
+			idMap[table.Id] = newTableId
+			table.Id = newTableId
+			table.ApplicationId = instance.Id
+			if _, err := session.Insert(table); err != nil {
+				return nil, err
+			}
+			for _, column := range columns {
+				newColumnId := model.ColumnConfigIdPrefix + util.GenerateDatabaseID()
+				idMap[column.Id] = newColumnId
+				column.Id = newColumnId
+				column.ApplicationId = instance.Id
+				column.TableId = table.Id
+				if _, err := session.Insert(column); err != nil {
+					return nil, err
+				}
+			}
+			for _, index := range indexes {
+				index.Id = model.TableIndexIdPrefix + util.GenerateDatabaseID()
+				index.ApplicationId = instance.Id
+				index.TableId = table.Id
+				for _, ic := range index.Columns {
+					ic.ColumnId = idMap[ic.ColumnId]
+				}
+				if _, err := session.Insert(index); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		var codeTemplates []*model.CodeTemplate
+		if err := session.Find(&codeTemplates, &model.CodeTemplate{ApplicationId: source.Id}); err != nil {
+			return nil, err
+		}
+		for _, codeTemplate := range codeTemplates {
+			codeTemplate.Id = model.CodeTemplateIdPrefix + util.GenerateDatabaseID()
+			codeTemplate.ApplicationId = instance.Id
+			if _, err := session.Insert(codeTemplate); err != nil {
+				return nil, err
+			}
+		}
+
+		var relations []*model.TableRelation
+		if err := session.Find(&relations, &model.TableRelation{ApplicationId: source.Id}); err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			relation.Id = model.TableRelationIdPrefix + util.GenerateDatabaseID()
+			relation.ApplicationId = instance.Id
+			relation.SourceTableId = idMap[relation.SourceTableId]
+			relation.SourceColumnId = idMap[relation.SourceColumnId]
+			relation.TargetTableId = idMap[relation.TargetTableId]
+			if _, err := session.Insert(relation); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return false, nil, err
 	}
-	return true, nil
+	return false, instance, nil
+}
+
+// parseEnumItems 解析字段的枚举值，字符串类及文本枚举以,分割，其余类型为 [{"key":1,"value":""}] 格式的json
+func parseEnumItems(column *model.ColumnConfig) ([]*model.EnumItem, error) {
+	var items []*model.EnumItem
+	if column.ColumnType == model.ColumnTypeString || column.ColumnType == model.ColumnTypeEnum || column.ColumnType == 0 {
+		for _, v := range strings.Split(column.EnumJson, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				items = append(items, &model.EnumItem{Key: v, Value: v})
+			}
+		}
+		return items, nil
+	}
+	var list []struct {
+		Key   json.Number `json:"key"`
+		Value string      `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(column.EnumJson), &list); err != nil {
+		return nil, err
+	}
+	for _, item := range list {
+		items = append(items, &model.EnumItem{Key: item.Key.String(), Value: item.Value})
+	}
+	return items, nil
 }
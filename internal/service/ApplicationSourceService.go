@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+	"xorm.io/xorm"
+
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/util"
+)
+
+var ApplicationSourceService = new(applicationSourceService)
+
+type applicationSourceService struct{}
+
+// ListByApplication 列出应用的所有源码包记录，不包含源码内容
+func (s *applicationSourceService) ListByApplication(applicationId string) ([]*model.ApplicationSource, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	var list []*model.ApplicationSource
+	err := database.DB.Omit("source", "snapshot").Desc("create_time").Find(&list, &model.ApplicationSource{ApplicationId: applicationId})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Get 获取源码包记录，包含源码内容
+func (s *applicationSourceService) Get(instance *model.ApplicationSource) (*model.ApplicationSource, error) {
+	if instance.Id == "" {
+		return nil, errors.New("ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	if instance.Size == 0 {
+		instance.Size = int64(len(instance.Source))
+	}
+	return instance, nil
+}
+
+// GetByVersion 获取应用指定版本的源码包记录
+func (s *applicationSourceService) GetByVersion(applicationId string, version int) (*model.ApplicationSource, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	// 版本号为0时xorm会忽略该条件，因此显式指定查询条件
+	if version <= 0 {
+		return nil, fmt.Errorf("版本号%d不正确", version)
+	}
+	instance := new(model.ApplicationSource)
+	has, err := database.DB.Where("application_id = ? AND version = ?", applicationId, version).Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+// NextVersion 计算应用下一个生成版本号
+func (s *applicationSourceService) NextVersion(applicationId string) (int, error) {
+	last := new(model.ApplicationSource)
+	has, err := database.DB.Cols("version").Where("application_id = ?", applicationId).Desc("version").Get(last)
+	if err != nil {
+		return 0, err
+	}
+	if !has {
+		return 1, nil
+	}
+	return last.Version + 1, nil
+}
+
+// insertNextVersion 在事务中为源码包分配下一个版本号并入库
+func (s *applicationSourceService) insertNextVersion(session *xorm.Session, source *model.ApplicationSource) error {
+	last := new(model.ApplicationSource)
+	has, err := session.Cols("version").Where("application_id = ?", source.ApplicationId).Desc("version").Get(last)
+	if err != nil {
+		return err
+	}
+	source.Version = 1
+	if has {
+		source.Version = last.Version + 1
+	}
+	_, err = session.Insert(source)
+	return err
+}
+
+// applicationSourceVersion 旧库补齐版本号时使用，不带唯一索引，以便先加上版本号字段
+type applicationSourceVersion struct {
+	Id            string `xorm:"pk varchar(50)"`
+	ApplicationId string `xorm:"varchar(50)"`
+	Version       int
+}
+
+func (applicationSourceVersion) TableName() string {
+	return database.DB.TableName(&model.ApplicationSource{})
+}
+
+// MigrateVersions 为旧版本未记录版本号的源码包按生成时间补齐版本号，须在同步版本号唯一索引之前执行
+func (s *applicationSourceService) MigrateVersions() error {
+	if exist, err := database.DB.IsTableExist(&model.ApplicationSource{}); err != nil || !exist {
+		return err
+	}
+	if err := database.DB.Sync2(new(applicationSourceVersion)); err != nil {
+		return err
+	}
+	return renumberVersions(&model.ApplicationSource{}, "application_id")
+}
+
+// renumberVersions 分组内的版本号存在缺失(<=0)或重复时，按创建时间重新编号
+func renumberVersions(bean interface{}, groupColumns ...string) error {
+	group := strings.Join(groupColumns, ", ")
+	rows, err := database.DB.Table(bean).Select("id, version, " + group).OrderBy(group + ", create_time, id").QueryString()
+	if err != nil {
+		return err
+	}
+	var groups [][]map[string]string
+	lastKey := ""
+	for i, row := range rows {
+		var key []string
+		for _, column := range groupColumns {
+			key = append(key, row[column])
+		}
+		if k := strings.Join(key, "\x00"); i == 0 || k != lastKey {
+			groups = append(groups, nil)
+			lastKey = k
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], row)
+	}
+	_, err = database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		for _, rows := range groups {
+			seen := make(map[string]bool)
+			valid := true
+			for _, row := range rows {
+				if v, err := strconv.Atoi(row["version"]); err != nil || v <= 0 || seen[row["version"]] {
+					valid = false
+					break
+				}
+				seen[row["version"]] = true
+			}
+			if valid {
+				continue
+			}
+			// 先改为负数再改为正数，避免已有唯一索引时中途冲突
+			for _, sign := range []int{-1, 1} {
+				for i, row := range rows {
+					if _, err := session.Table(bean).ID(row["id"]).Update(map[string]interface{}{"version": sign * (i + 1)}); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Diff 按文件比较应用两个生成版本的源码差异，未变化的文件不返回
+func (s *applicationSourceService) Diff(applicationId string, fromVersion, toVersion int) ([]*model.SourceFileDiff, error) {
+	from, err := s.GetByVersion(applicationId, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, fmt.Errorf("版本%d不存在", fromVersion)
+	}
+	to, err := s.GetByVersion(applicationId, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, fmt.Errorf("版本%d不存在", toVersion)
+	}
+	fromFiles, err := util.ReadZipFiles(from.Source)
+	if err != nil {
+		return nil, err
+	}
+	toFiles, err := util.ReadZipFiles(to.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]struct{})
+	for p := range fromFiles {
+		paths[p] = struct{}{}
+	}
+	for p := range toFiles {
+		paths[p] = struct{}{}
+	}
+	sortedPaths := make([]string, 0, len(paths))
+	for p := range paths {
+		sortedPaths = append(sortedPaths, p)
+	}
+	sort.Strings(sortedPaths)
+
+	diffs := make([]*model.SourceFileDiff, 0)
+	for _, p := range sortedPaths {
+		fromContent, inFrom := fromFiles[p]
+		toContent, inTo := toFiles[p]
+		d := &model.SourceFileDiff{Path: p}
+		switch {
+		case !inFrom:
+			d.Status = model.SourceFileAdded
+		case !inTo:
+			d.Status = model.SourceFileRemoved
+		case string(fromContent) != string(toContent):
+			d.Status = model.SourceFileChanged
+		default:
+			continue
+		}
+		d.Diff = util.UnifiedDiff("a/"+p, "b/"+p, string(fromContent), string(toContent))
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
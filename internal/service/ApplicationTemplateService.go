@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/util"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var ApplicationTemplateService = new(applicationTemplateService)
+
+type applicationTemplateService struct{}
+
+// SaveFromApplication 将已有应用的表及字段设计保存为模板
+func (s *applicationTemplateService) SaveFromApplication(req *model.SaveTemplateRequest, ownerId string) (isDuplicated bool, instance *model.ApplicationTemplate, err error) {
+	if req.ApplicationId == "" {
+		return false, nil, errors.New("应用ID不能为空")
+	}
+	if req.TemplateName == "" {
+		return false, nil, errors.New("模板名称不能为空")
+	}
+	var c int64 = 0
+	c, err = database.DB.Count(&model.ApplicationTemplate{TemplateName: req.TemplateName})
+	if err != nil {
+		return
+	}
+	if c > 0 {
+		isDuplicated = true
+		return
+	}
+	manifest, err := ApplicationManifestService.Export(req.ApplicationId)
+	if err != nil {
+		return false, nil, err
+	}
+	instance = &model.ApplicationTemplate{
+		Id:           model.ApplicationTemplateIdPrefix + util.GenerateDatabaseID(),
+		TemplateName: req.TemplateName,
+		TemplateDesc: req.TemplateDesc,
+		TableCount:   len(manifest.Tables),
+		Manifest:     manifest,
+		OwnerId:      ownerId,
+	}
+	if instance.TemplateDesc == "" {
+		instance.TemplateDesc = manifest.Application.AppDesc
+	}
+	if _, err = database.DB.Insert(instance); err != nil {
+		return false, nil, err
+	}
+	return false, instance, nil
+}
+
+// EnsureBuiltin 按模板名称写入内置模板，已存在的内置模板更新为最新内容
+func (s *applicationTemplateService) EnsureBuiltin(templates []*model.ApplicationTemplate) error {
+	for _, template := range templates {
+		if conflicts := validateManifest(template.Manifest); len(conflicts) > 0 {
+			return errors.New("内置模板" + template.TemplateName + "不正确: " + conflicts[0])
+		}
+		template.Builtin = 1
+		template.TableCount = len(template.Manifest.Tables)
+		old := &model.ApplicationTemplate{TemplateName: template.TemplateName}
+		has, err := database.DB.Cols("id", "builtin").Get(old)
+		if err != nil {
+			return err
+		}
+		if has {
+			if old.Builtin != 1 {
+				// 同名的用户模板不覆盖
+				continue
+			}
+			if _, err = database.DB.ID(old.Id).Cols("template_desc", "table_count", "manifest").Update(template); err != nil {
+				return err
+			}
+			continue
+		}
+		template.Id = model.ApplicationTemplateIdPrefix + util.GenerateDatabaseID()
+		if _, err = database.DB.Insert(template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *applicationTemplateService) Remove(instance *model.ApplicationTemplate) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("id不能为空")
+	}
+	// 内置模板不允许删除
+	c, err := database.DB.Where("builtin <> ?", 1).Delete(instance)
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *applicationTemplateService) Update(instance *model.ApplicationTemplate) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("ID不能为空")
+	}
+	// 不允许更改的字段
+	if instance.TemplateName != "" {
+		c, err := database.DB.Where("id <> ?", instance.Id).Count(&model.ApplicationTemplate{TemplateName: instance.TemplateName})
+		if err != nil {
+			return false, err
+		}
+		if c > 0 {
+			return false, errors.New("模板名称已存在")
+		}
+	}
+
+	// 内置模板不允许更改
+	c, err := database.DB.ID(instance.Id).Where("builtin <> ?", 1).Update(&model.ApplicationTemplate{
+		// 允许更改的字段
+		TemplateName: instance.TemplateName,
+		TemplateDesc: instance.TemplateDesc,
+	})
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *applicationTemplateService) Get(instance *model.ApplicationTemplate) (*model.ApplicationTemplate, error) {
+	if instance.Id == "" {
+		return nil, errors.New("ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+func (s *applicationTemplateService) Paginate(condition *model.ApplicationTemplate, limit, offset int, orderBy string) (int, []*model.ApplicationTemplate, error) {
+	return s.PaginateBetweenTimes(condition, limit, offset, orderBy, nil)
+}
+
+// PaginateBetweenTimes 分页列出模板，不包含模板内容
+func (s *applicationTemplateService) PaginateBetweenTimes(condition *model.ApplicationTemplate, limit, offset int, orderBy string, tcList map[string]*domain.TimeCondition) (int, []*model.ApplicationTemplate, error) {
+	// 处理不允许查询的字段
+	condition.Manifest = nil
+
+	// 处理sql
+	session := database.DB.NewSession()
+	session.Omit("manifest")
+	if limit > -1 && offset > -1 {
+		session.Limit(limit, offset)
+	}
+
+	if orderBy != "" {
+		session.OrderBy(orderBy)
+	}
+	// 内置模板在前
+	session.Desc("builtin", "create_time")
+
+	// 处理时间字段，在某段时间之间
+	for tc, tr := range tcList {
+		if tc != "" {
+			if !tr.Start.IsZero() && !tr.End.IsZero() {
+				session.Where(tc+" between ? and ?", tr.Start, tr.End)
+			} else if tr.Start.IsZero() {
+				session.Where(tc+" <= ?", tr.End)
+			} else if tr.End.IsZero() {
+				session.Where(tc+" > ?", tr.Start)
+			}
+		}
+	}
+
+	// 模糊查找
+	if condition.TemplateName != "" {
+		session.Where("template_name like ?", condition.TemplateName+"%")
+		condition.TemplateName = ""
+	}
+	if condition.TemplateDesc != "" {
+		session.Where("template_desc like ?", condition.TemplateDesc+"%")
+		condition.TemplateDesc = ""
+	}
+	var list []*model.ApplicationTemplate
+	total, err := session.FindAndCount(&list, condition)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(total), list, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"github.com/yockii/qscore/pkg/database"
+	"xorm.io/xorm"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+// cascadeTarget 级联删除中同一数据表待删除的记录，按ID列表或查询条件指定
+type cascadeTarget struct {
+	name  string
+	bean  interface{}
+	ids   []string
+	where string
+	args  []interface{}
+}
+
+// cascadePlan 级联删除计划，按加入顺序删除，子记录应先于父记录加入
+type cascadePlan struct {
+	targets []*cascadeTarget
+}
+
+func (p *cascadePlan) add(name string, bean interface{}, ids ...[]string) {
+	seen := make(map[string]bool)
+	target := &cascadeTarget{name: name, bean: bean}
+	for _, list := range ids {
+		for _, id := range list {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				target.ids = append(target.ids, id)
+			}
+		}
+	}
+	p.targets = append(p.targets, target)
+}
+
+// addWhere 按条件删除，条件在删除事务中求值，事务开始前新写入的记录同样会被删除
+func (p *cascadePlan) addWhere(name string, bean interface{}, where string, args ...interface{}) {
+	p.targets = append(p.targets, &cascadeTarget{name: name, bean: bean, where: where, args: args})
+}
+
+// preview 统计计划将删除的记录数，不做删除
+func (p *cascadePlan) preview() (*model.CascadeDeleteResult, error) {
+	result := &model.CascadeDeleteResult{DryRun: true, Counts: make(map[string]int64)}
+	for _, target := range p.targets {
+		c := int64(len(target.ids))
+		if target.where != "" {
+			var err error
+			if c, err = database.DB.Where(target.where, target.args...).Count(target.bean); err != nil {
+				return nil, err
+			}
+		}
+		result.Counts[target.name] += c
+		result.Total += c
+	}
+	return result, nil
+}
+
+// execute 在同一事务中按计划删除，返回实际删除的记录数
+func (p *cascadePlan) execute() (*model.CascadeDeleteResult, error) {
+	result := &model.CascadeDeleteResult{Counts: make(map[string]int64)}
+	_, err := database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		for _, target := range p.targets {
+			if target.where != "" {
+				c, err := session.Where(target.where, target.args...).Delete(target.bean)
+				if err != nil {
+					return nil, err
+				}
+				result.Counts[target.name] += c
+				result.Total += c
+				continue
+			}
+			// 分批删除，避免in条件过长
+			for start := 0; start < len(target.ids); start += 500 {
+				end := start + 500
+				if end > len(target.ids) {
+					end = len(target.ids)
+				}
+				c, err := session.In("id", target.ids[start:end]).Delete(target.bean)
+				if err != nil {
+					return nil, err
+				}
+				result.Counts[target.name] += c
+				result.Total += c
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
+
+	gDomain "github.com/yockii/qs-code-generator/pkg/domain"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var CodeTemplateService = new(codeTemplateService)
+
+type codeTemplateService struct{}
+
+// checkKind 当前依赖的生成器没有提供文件类型列表，只校验文件类型的格式
+func (s *codeTemplateService) checkKind(kind string) error {
+	if kind == "" {
+		return errors.New("文件类型不能为空")
+	}
+	if len(kind) > 50 || strings.ContainsAny(kind, " \t\r\n") {
+		return fmt.Errorf("不支持的文件类型%s", kind)
+	}
+	return nil
+}
+
+// Add 上传模板，作为该文件类型的新版本；内容与最新版本相同时视为重复
+func (s *codeTemplateService) Add(instance *model.CodeTemplate) (isDuplicated bool, success bool, err error) {
+	if instance.ApplicationId == "" {
+		return false, false, errors.New("应用ID不能为空")
+	}
+	if instance.Content == "" {
+		return false, false, errors.New("模板内容不能为空")
+	}
+	if err = s.checkKind(instance.Kind); err != nil {
+		return
+	}
+	// 在事务中分配版本号，唯一索引保证并发上传时不会得到相同的版本号
+	_, err = database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		if exist, err := session.Exist(&model.Application{Id: instance.ApplicationId}); err != nil {
+			return nil, err
+		} else if !exist {
+			return nil, errors.New("ID所指向的应用不存在")
+		}
+		latest := new(model.CodeTemplate)
+		has, err := session.Where("application_id = ? and kind = ?", instance.ApplicationId, instance.Kind).Desc("version").ForUpdate().Get(latest)
+		if err != nil {
+			return nil, err
+		}
+		if has && latest.Content == instance.Content {
+			isDuplicated = true
+			return nil, nil
+		}
+		instance.Version = latest.Version + 1
+		instance.Id = model.CodeTemplateIdPrefix + util.GenerateDatabaseID()
+		_, err = session.Insert(instance)
+		return nil, err
+	})
+	success = err == nil && !isDuplicated
+	return
+}
+
+func (s *codeTemplateService) Remove(instance *model.CodeTemplate) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("id不能为空")
+	}
+	c, err := database.DB.Delete(instance)
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Reset 删除应用某文件类型的全部版本
+func (s *codeTemplateService) Reset(applicationId string, kind string) (int64, error) {
+	if applicationId == "" || kind == "" {
+		return 0, errors.New("应用ID及文件类型不能为空")
+	}
+	return database.DB.Delete(&model.CodeTemplate{ApplicationId: applicationId, Kind: kind})
+}
+
+// Update 模板内容不可更改，更改内容请上传新版本
+func (s *codeTemplateService) Update(instance *model.CodeTemplate) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("ID不能为空")
+	}
+	// 不允许更改的字段
+
+	c, err := database.DB.ID(instance.Id).Update(&model.CodeTemplate{
+		// 允许更改的字段
+		Remark: instance.Remark,
+	})
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *codeTemplateService) Get(instance *model.CodeTemplate) (*model.CodeTemplate, error) {
+	if instance.Id == "" {
+		return nil, errors.New("ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+func (s *codeTemplateService) Paginate(condition *model.CodeTemplate, limit, offset int, orderBy string) (int, []*model.CodeTemplate, error) {
+	return s.PaginateBetweenTimes(condition, limit, offset, orderBy, nil)
+}
+
+// PaginateBetweenTimes 分页列出模板版本，不包含模板内容
+func (s *codeTemplateService) PaginateBetweenTimes(condition *model.CodeTemplate, limit, offset int, orderBy string, tcList map[string]*domain.TimeCondition) (int, []*model.CodeTemplate, error) {
+	// 处理不允许查询的字段
+	condition.Content = ""
+
+	// 处理sql
+	session := database.DB.NewSession()
+	session.Omit("content")
+	if limit > -1 && offset > -1 {
+		session.Limit(limit, offset)
+	}
+
+	if orderBy != "" {
+		session.OrderBy(orderBy)
+	}
+	session.Asc("kind").Desc("version")
+
+	// 处理时间字段，在某段时间之间
+	for tc, tr := range tcList {
+		if tc != "" {
+			if !tr.Start.IsZero() && !tr.End.IsZero() {
+				session.Where(tc+" between ? and ?", tr.Start, tr.End)
+			} else if tr.Start.IsZero() {
+				session.Where(tc+" <= ?", tr.End)
+			} else if tr.End.IsZero() {
+				session.Where(tc+" > ?", tr.Start)
+			}
+		}
+	}
+
+	var list []*model.CodeTemplate
+	total, err := session.FindAndCount(&list, condition)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(total), list, nil
+}
+
+// Latest 获取应用某文件类型的最新版本模板，没有自定义模板时返回nil
+func (s *codeTemplateService) Latest(applicationId string, kind string) (*model.CodeTemplate, error) {
+	instance := new(model.CodeTemplate)
+	has, err := database.DB.Where("application_id = ? and kind = ?", applicationId, kind).Desc("version").Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+// ListLatest 列出应用每个文件类型的最新版本模板
+func (s *codeTemplateService) ListLatest(applicationId string) ([]*model.CodeTemplate, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	var list []*model.CodeTemplate
+	if err := database.DB.Asc("kind").Desc("version").Find(&list, &model.CodeTemplate{ApplicationId: applicationId}); err != nil {
+		return nil, err
+	}
+	var result []*model.CodeTemplate
+	seen := make(map[string]bool)
+	for _, instance := range list {
+		if !seen[instance.Kind] {
+			seen[instance.Kind] = true
+			result = append(result, instance)
+		}
+	}
+	return result, nil
+}
+
+// MigrateVersions 并发上传产生的重复版本号按上传时间重新编号，须在同步版本号唯一索引之前执行
+func (s *codeTemplateService) MigrateVersions() error {
+	if exist, err := database.DB.IsTableExist(&model.CodeTemplate{}); err != nil || !exist {
+		return err
+	}
+	return renumberVersions(&model.CodeTemplate{}, "application_id", "kind")
+}
+
+// Preview 以一张表渲染模板，模板的解析及执行错误在结果中返回
+func (s *codeTemplateService) Preview(req *model.CodeTemplatePreviewRequest) (*model.CodeTemplatePreviewResult, error) {
+	if req.ApplicationId == "" || req.TableId == "" {
+		return nil, errors.New("应用ID及表ID不能为空")
+	}
+	if err := s.checkKind(req.Kind); err != nil {
+		return nil, err
+	}
+	content := req.Content
+	if content == "" {
+		latest, err := s.Latest(req.ApplicationId, req.Kind)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil {
+			return nil, fmt.Errorf("文件类型%s没有自定义模板", req.Kind)
+		}
+		content = latest.Content
+	}
+	table := new(model.TableConfig)
+	if has, err := database.DB.ID(req.TableId).Get(table); err != nil {
+		return nil, err
+	} else if !has || table.ApplicationId != req.ApplicationId {
+		return nil, errors.New("表不存在或不属于该应用")
+	}
+
+	app, _, err := ApplicationService.buildGeneratorApplication(req.ApplicationId)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.CodeTemplatePreviewResult{Kind: req.Kind, Table: table.TableName}
+	for _, gtable := range app.Tables {
+		if gtable.Name != table.TableName {
+			continue
+		}
+		output, err := renderCodeTemplate(app, gtable, req.Kind, content)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Output = output
+		}
+		return result, nil
+	}
+	return nil, errors.New("表不存在或不属于该应用")
+}
+
+// renderCodeTemplate 以生成器的应用及表结构为数据执行模板，模板中通过 .Application、.Table 访问
+func renderCodeTemplate(app *gDomain.Application, table *gDomain.Table, kind string, content string) (string, error) {
+	tpl, err := template.New(kind).Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, map[string]interface{}{
+		"Application": app,
+		"Table":       table,
+	}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/yockii/qscore/pkg/database"
 	"github.com/yockii/qscore/pkg/domain"
 	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
 
 	"github.com/yockii/quick-system/internal/model"
 )
@@ -24,6 +27,12 @@ func (s *columnConfigService) Add(instance *model.ColumnConfig) (isDuplicated bo
 	if instance.ColumnName == "" {
 		return false, false, errors.New("字段名不能为空")
 	}
+	if instance.ColumnType != 0 && model.GetColumnTypeSpec(instance.ColumnType) == nil {
+		return false, false, errors.New("字段类型不正确")
+	}
+	if _, err = requireTable(instance.TableId, instance.ApplicationId); err != nil {
+		return
+	}
 	var c int64 = 0
 	c, err = database.DB.Count(&model.ColumnConfig{
 		ApplicationId: instance.ApplicationId,
@@ -39,8 +48,24 @@ func (s *columnConfigService) Add(instance *model.ColumnConfig) (isDuplicated bo
 	}
 
 	instance.Id = model.ColumnConfigIdPrefix + util.GenerateDatabaseID()
+	applyColumnDefaults(instance)
+	if instance.SortOrder == 0 {
+		// 新增的字段排在最后
+		last := new(model.ColumnConfig)
+		if _, err = database.DB.Where("table_id = ?", instance.TableId).Desc("sort_order").Cols("sort_order").Get(last); err != nil {
+			return
+		}
+		instance.SortOrder = last.SortOrder + 1
+	}
+	_, err = database.DB.Insert(instance)
+	success = err == nil
+	return
+}
+
+// applyColumnDefaults 为未设置的字段属性填充默认值
+func applyColumnDefaults(instance *model.ColumnConfig) {
 	if instance.ColumnType == 0 {
-		instance.ColumnType = 1
+		instance.ColumnType = model.ColumnTypeString
 	}
 	if instance.UpdateType == 0 {
 		instance.UpdateType = 7
@@ -51,15 +76,21 @@ func (s *columnConfigService) Add(instance *model.ColumnConfig) (isDuplicated bo
 	if instance.StringSearch == 0 {
 		instance.StringSearch = 1
 	}
-	_, err = database.DB.Insert(instance)
-	success = err == nil
-	return
 }
 
+// Remove 删除字段，被索引或表关联引用的字段不能删除
 func (s *columnConfigService) Remove(instance *model.ColumnConfig) (bool, error) {
 	if instance.Id == "" {
 		return false, errors.New("id不能为空")
 	}
+	old := new(model.ColumnConfig)
+	if has, err := database.DB.ID(instance.Id).Get(old); err != nil {
+		return false, err
+	} else if has {
+		if err = s.checkUnreferenced(old, "删除"); err != nil {
+			return false, err
+		}
+	}
 	c, err := database.DB.Delete(instance)
 	if err != nil {
 		return false, err
@@ -75,13 +106,23 @@ func (s *columnConfigService) Update(instance *model.ColumnConfig) (bool, error)
 		return false, errors.New("ID不能为空")
 	}
 	// 不允许更改的字段
+	if instance.ColumnType != 0 && model.GetColumnTypeSpec(instance.ColumnType) == nil {
+		return false, errors.New("字段类型不正确")
+	}
+	if instance.ApplicationId != "" || instance.TableId != "" {
+		if err := s.validateMove(instance); err != nil {
+			return false, err
+		}
+	}
 
 	c, err := database.DB.ID(instance.Id).Update(&model.ColumnConfig{
 		// 允许更改的字段
 		ApplicationId: instance.ApplicationId,
 		TableId:       instance.TableId,
 		ColumnName:    instance.ColumnName,
+		DisplayName:   instance.DisplayName,
 		ColumnComment: instance.ColumnComment,
+		DisplayType:   instance.DisplayType,
 		ColumnType:    instance.ColumnType,
 		UpdateType:    instance.UpdateType,
 		UpdateAlone:   instance.UpdateAlone,
@@ -92,6 +133,7 @@ func (s *columnConfigService) Update(instance *model.ColumnConfig) (bool, error)
 		EnumJson:      instance.EnumJson,
 		ColumnLength:  instance.ColumnLength,
 		DecimalLength: instance.DecimalLength,
+		SortOrder:     instance.SortOrder,
 	})
 	if err != nil {
 		return false, err
@@ -102,6 +144,67 @@ func (s *columnConfigService) Update(instance *model.ColumnConfig) (bool, error)
 	return true, nil
 }
 
+// validateMove 校验更改字段所属的表或应用后仍然一致：目标表必须属于字段原来的应用，
+// 被索引或表关联引用的字段不能移动到其他表
+func (s *columnConfigService) validateMove(instance *model.ColumnConfig) error {
+	old := new(model.ColumnConfig)
+	if has, err := database.DB.ID(instance.Id).Get(old); err != nil {
+		return err
+	} else if !has {
+		return nil
+	}
+	tableId := instance.TableId
+	if tableId == "" {
+		tableId = old.TableId
+	}
+	table, err := requireTable(tableId, instance.ApplicationId)
+	if err != nil {
+		return err
+	}
+	instance.ApplicationId = table.ApplicationId
+	if tableId == old.TableId {
+		return nil
+	}
+
+	if old.ApplicationId != "" && old.ApplicationId != table.ApplicationId {
+		return parentMismatch("字段不能移动到其他应用的表")
+	}
+	if err := s.checkUnreferenced(old, "移动到其他表"); err != nil {
+		return err
+	}
+	name := instance.ColumnName
+	if name == "" {
+		name = old.ColumnName
+	}
+	if c, err := database.DB.Count(&model.ColumnConfig{TableId: tableId, ColumnName: name}); err != nil {
+		return err
+	} else if c > 0 {
+		return fmt.Errorf("表%s已存在字段%s", table.TableName, name)
+	}
+	return nil
+}
+
+// checkUnreferenced 校验字段未被表关联或索引引用，action为被拒绝的操作
+func (s *columnConfigService) checkUnreferenced(column *model.ColumnConfig, action string) error {
+	if c, err := database.DB.Count(&model.TableRelation{SourceColumnId: column.Id}); err != nil {
+		return err
+	} else if c > 0 {
+		return parentMismatch("字段%s已被表关联引用，不能%s", column.ColumnName, action)
+	}
+	var indexes []*model.TableIndex
+	if err := database.DB.Find(&indexes, &model.TableIndex{TableId: column.TableId}); err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		for _, ic := range index.Columns {
+			if ic != nil && ic.ColumnId == column.Id {
+				return parentMismatch("字段%s已被索引%s引用，不能%s", column.ColumnName, index.IndexName, action)
+			}
+		}
+	}
+	return nil
+}
+
 func (s *columnConfigService) Get(instance *model.ColumnConfig) (*model.ColumnConfig, error) {
 	if instance.Id == "" {
 		return nil, errors.New("ID不能为空")
@@ -132,7 +235,7 @@ func (s *columnConfigService) PaginateBetweenTimes(condition *model.ColumnConfig
 	if orderBy != "" {
 		session.OrderBy(orderBy)
 	}
-	session.Desc("create_time")
+	session.Asc("sort_order", "id")
 
 	// 处理时间字段，在某段时间之间
 	for tc, tr := range tcList {
@@ -171,3 +274,97 @@ func (s *columnConfigService) PaginateBetweenTimes(condition *model.ColumnConfig
 	}
 	return int(total), list, nil
 }
+
+// Reorder 按ids的顺序重新设置表内所有字段的排序，ids必须包含表的全部字段
+func (s *columnConfigService) Reorder(tableId string, ids []string) error {
+	if tableId == "" {
+		return errors.New("表ID不能为空")
+	}
+	var columns []*model.ColumnConfig
+	if err := database.DB.Cols("id").Find(&columns, &model.ColumnConfig{TableId: tableId}); err != nil {
+		return err
+	}
+	existing := make([]string, 0, len(columns))
+	for _, column := range columns {
+		existing = append(existing, column.Id)
+	}
+	if err := checkReorderIds(existing, ids); err != nil {
+		return err
+	}
+	_, err := database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		for i, id := range ids {
+			if _, err := session.ID(id).Cols("sort_order").Update(&model.ColumnConfig{SortOrder: i + 1}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// columnSqlType 字段在数据库中的类型定义
+func columnSqlType(column *model.ColumnConfig) string {
+	switch column.ColumnType {
+	case model.ColumnTypeInt:
+		if column.ColumnLength > 0 {
+			return fmt.Sprintf("int(%d)", column.ColumnLength)
+		}
+		return "int"
+	case model.ColumnTypeDateTime:
+		return "datetime"
+	case model.ColumnTypeDecimal:
+		if column.ColumnLength > 0 {
+			return fmt.Sprintf("decimal(%d,%d)", column.ColumnLength, column.DecimalLength)
+		}
+		return "decimal(10,2)"
+	case model.ColumnTypeBool:
+		return "tinyint(1)"
+	case model.ColumnTypeBigInt:
+		return "bigint"
+	case model.ColumnTypeFloat:
+		return "double"
+	case model.ColumnTypeDate:
+		return "date"
+	case model.ColumnTypeTime:
+		return "time"
+	case model.ColumnTypeJson:
+		return "json"
+	case model.ColumnTypeEnum:
+		var values []string
+		for _, v := range strings.Split(column.EnumJson, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, "'"+strings.ReplaceAll(v, "'", "''")+"'")
+			}
+		}
+		if len(values) == 0 {
+			return "varchar(50)"
+		}
+		return "enum(" + strings.Join(values, ",") + ")"
+	case model.ColumnTypeFile:
+		if column.ColumnLength > 0 {
+			return fmt.Sprintf("varchar(%d)", column.ColumnLength)
+		}
+		return "varchar(500)"
+	}
+	if column.StringType == model.StringTypeLongtext {
+		return "longtext"
+	}
+	if column.ColumnLength > 0 {
+		return fmt.Sprintf("varchar(%d)", column.ColumnLength)
+	}
+	return "varchar(255)"
+}
+
+// MigrateColumnTypes 迁移旧版本的字段类型数据:
+// 未设置类型的按字符串处理；int类型设置了小数位数的，旧版本按decimal生成，迁移为decimal类型
+func (s *columnConfigService) MigrateColumnTypes() error {
+	if _, err := database.DB.Where("column_type = ? or column_type is null", 0).Cols("column_type").Update(&model.ColumnConfig{
+		ColumnType: model.ColumnTypeString,
+	}); err != nil {
+		return err
+	}
+	_, err := database.DB.Where("column_type = ? and decimal_length > ?", model.ColumnTypeInt, 0).Cols("column_type").Update(&model.ColumnConfig{
+		ColumnType: model.ColumnTypeDecimal,
+	})
+	return err
+}
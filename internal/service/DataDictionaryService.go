@@ -0,0 +1,450 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var DataDictionaryService = new(dataDictionaryService)
+
+type dataDictionaryService struct{}
+
+// dictionaryHeaders 字段表的列
+var dictionaryHeaders = []string{"字段名", "显示名", "类型", "长度", "允许为空", "默认值", "唯一分组", "搜索方式", "枚举值", "说明"}
+
+type dictionaryTable struct {
+	name       string
+	comment    string
+	recordType string
+	rows       [][]string
+}
+
+type dictionary struct {
+	appName string
+	pkg     string
+	appDesc string
+	tables  []*dictionaryTable
+}
+
+// Export 导出应用的数据字典，返回文档内容及文件扩展名
+func (s *dataDictionaryService) Export(applicationId string, format string) ([]byte, string, error) {
+	if applicationId == "" {
+		return nil, "", errors.New("应用ID不能为空")
+	}
+	application := new(model.Application)
+	if exist, err := database.DB.ID(applicationId).Get(application); err != nil {
+		return nil, "", err
+	} else if !exist {
+		return nil, "", errors.New("ID所指向的应用不存在")
+	}
+	tables, err := TableConfigService.ListWithColumns(applicationId)
+	if err != nil {
+		return nil, "", err
+	}
+	dict, err := buildDictionary(application, tables)
+	if err != nil {
+		return nil, "", err
+	}
+	switch format {
+	case model.DictionaryFormatMarkdown, "":
+		return renderDictionaryMarkdown(dict), "md", nil
+	case model.DictionaryFormatHtml:
+		return renderDictionaryHtml(dict), "html", nil
+	case model.DictionaryFormatXlsx:
+		bs, err := renderDictionaryXlsx(dict)
+		return bs, "xlsx", err
+	}
+	return nil, "", fmt.Errorf("不支持的导出格式%s", format)
+}
+
+func buildDictionary(application *model.Application, tables []*model.TableSnapshot) (*dictionary, error) {
+	dict := &dictionary{appName: application.AppName, pkg: application.Package, appDesc: application.AppDesc}
+	for _, table := range tables {
+		dt := &dictionaryTable{name: table.TableName, comment: table.TableComment}
+		var flags []string
+		for _, rt := range []struct {
+			bit  int
+			name string
+		}{
+			{model.RecordTypeCreateTime, "记录创建时间"},
+			{model.RecordTypeUpdateTime, "记录更新时间"},
+			{model.RecordTypeDeleteTime, "记录删除时间(逻辑删除)"},
+		} {
+			if table.RecordType&rt.bit == rt.bit {
+				flags = append(flags, rt.name)
+			}
+		}
+		dt.recordType = strings.Join(flags, "、")
+		if dt.recordType == "" {
+			dt.recordType = "无"
+		}
+
+		dt.rows = append(dt.rows, []string{"id", "ID", "string", "50", "否", "", "", "", "", "主键"})
+		for _, column := range table.Columns {
+			name := strings.ToLower(column.ColumnName)
+			if _, ok := recordTimeColumns[name]; ok || name == "id" {
+				continue
+			}
+			row, err := dictionaryRow(column)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s的枚举值不正确: %s", table.TableName, column.ColumnName, err.Error())
+			}
+			dt.rows = append(dt.rows, row)
+		}
+		for _, rt := range []struct {
+			bit         int
+			name        string
+			displayName string
+			comment     string
+		}{
+			{model.RecordTypeCreateTime, "create_time", "创建时间", "由系统维护"},
+			{model.RecordTypeUpdateTime, "update_time", "更新时间", "由系统维护"},
+			{model.RecordTypeDeleteTime, "delete_time", "删除时间", "由系统维护，不为空表示已删除"},
+		} {
+			if table.RecordType&rt.bit == rt.bit {
+				dt.rows = append(dt.rows, []string{rt.name, rt.displayName, "datetime", "", "是", "", "", "", "", rt.comment})
+			}
+		}
+		dict.tables = append(dict.tables, dt)
+	}
+	return dict, nil
+}
+
+func dictionaryRow(column *model.ColumnConfig) ([]string, error) {
+	typeName := "string"
+	if spec := model.GetColumnTypeSpec(column.ColumnType); spec != nil {
+		typeName = spec.Name
+	}
+	length := ""
+	switch column.ColumnType {
+	case 0, model.ColumnTypeString:
+		if column.StringType == model.StringTypeLongtext {
+			typeName = "longtext"
+		} else {
+			length = strconv.Itoa(lengthOr(column.ColumnLength, 255))
+		}
+	case model.ColumnTypeDecimal:
+		if column.ColumnLength > 0 {
+			length = fmt.Sprintf("%d,%d", column.ColumnLength, column.DecimalLength)
+		} else {
+			length = "10,2"
+		}
+	case model.ColumnTypeFile:
+		length = strconv.Itoa(lengthOr(column.ColumnLength, 500))
+	}
+
+	nullable, defaultValue := "是", ""
+	switch column.ZeroValue {
+	case "":
+	case model.ZeroValueNotNull:
+		nullable = "否"
+	default:
+		defaultValue = column.ZeroValue
+	}
+	unique := ""
+	if column.UniqueCheck > 0 {
+		unique = strconv.Itoa(column.UniqueCheck)
+	}
+	search := ""
+	if column.UpdateType&model.UpdateTypeSearch == model.UpdateTypeSearch {
+		search = "精确匹配"
+		switch column.ColumnType {
+		case 0, model.ColumnTypeString, model.ColumnTypeEnum, model.ColumnTypeFile:
+			switch column.StringSearch {
+			case model.StringSearchPrefix:
+				search = "开头模糊匹配"
+			case model.StringSearchFull:
+				search = "全量模糊匹配"
+			}
+		}
+	}
+	var enums []string
+	if column.EnumJson != "" {
+		items, err := parseEnumItems(column)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if item.Key == item.Value {
+				enums = append(enums, item.Key)
+			} else {
+				enums = append(enums, item.Key+"-"+item.Value)
+			}
+		}
+	}
+	return []string{
+		column.ColumnName,
+		column.DisplayName,
+		typeName,
+		length,
+		nullable,
+		defaultValue,
+		unique,
+		search,
+		strings.Join(enums, "，"),
+		column.ColumnComment,
+	}, nil
+}
+
+func (d *dictionary) title() string {
+	if d.appName != "" {
+		return d.appName + " 数据字典"
+	}
+	return d.pkg + " 数据字典"
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r", "")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func renderDictionaryMarkdown(d *dictionary) []byte {
+	var sb strings.Builder
+	sb.WriteString("# " + d.title() + "\n\n")
+	if d.pkg != "" {
+		sb.WriteString("包名: `" + d.pkg + "`\n\n")
+	}
+	if d.appDesc != "" {
+		sb.WriteString(d.appDesc + "\n\n")
+	}
+	sb.WriteString("## 目录\n\n")
+	for i, table := range d.tables {
+		sb.WriteString(fmt.Sprintf("%d. [%s](#%s) %s\n", i+1, table.name, strings.ToLower(table.name), markdownCell(table.comment)))
+	}
+	sb.WriteString("\n")
+	for _, table := range d.tables {
+		sb.WriteString("## " + table.name + "\n\n")
+		if table.comment != "" {
+			sb.WriteString(table.comment + "\n\n")
+		}
+		sb.WriteString("记录类型: " + table.recordType + "\n\n")
+		sb.WriteString("| " + strings.Join(dictionaryHeaders, " | ") + " |\n")
+		sb.WriteString("|" + strings.Repeat(" --- |", len(dictionaryHeaders)) + "\n")
+		for _, row := range table.rows {
+			cells := make([]string, 0, len(row))
+			for _, cell := range row {
+				cells = append(cells, markdownCell(cell))
+			}
+			sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		sb.WriteString("\n")
+	}
+	return []byte(sb.String())
+}
+
+const dictionaryHtmlStyle = `body{font-family:-apple-system,"Segoe UI","Microsoft YaHei",sans-serif;margin:32px;color:#333}
+h1{border-bottom:2px solid #eee;padding-bottom:8px}
+h2{margin-top:40px}
+table{border-collapse:collapse;width:100%;font-size:14px}
+th,td{border:1px solid #ddd;padding:6px 8px;text-align:left;vertical-align:top}
+th{background:#f5f5f5}
+tr:nth-child(even) td{background:#fafafa}
+.meta{color:#666}`
+
+func renderDictionaryHtml(d *dictionary) []byte {
+	var sb strings.Builder
+	title := html.EscapeString(d.title())
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + title + "</title>\n<style>\n" + dictionaryHtmlStyle + "\n</style>\n</head>\n<body>\n")
+	sb.WriteString("<h1>" + title + "</h1>\n")
+	if d.pkg != "" {
+		sb.WriteString("<p class=\"meta\">包名: <code>" + html.EscapeString(d.pkg) + "</code></p>\n")
+	}
+	if d.appDesc != "" {
+		sb.WriteString("<p>" + html.EscapeString(d.appDesc) + "</p>\n")
+	}
+	sb.WriteString("<h2>目录</h2>\n<ol>\n")
+	for _, table := range d.tables {
+		name := html.EscapeString(table.name)
+		sb.WriteString("<li><a href=\"#table-" + name + "\">" + name + "</a> " + html.EscapeString(table.comment) + "</li>\n")
+	}
+	sb.WriteString("</ol>\n")
+	for _, table := range d.tables {
+		name := html.EscapeString(table.name)
+		sb.WriteString("<h2 id=\"table-" + name + "\">" + name + "</h2>\n")
+		if table.comment != "" {
+			sb.WriteString("<p>" + html.EscapeString(table.comment) + "</p>\n")
+		}
+		sb.WriteString("<p class=\"meta\">记录类型: " + html.EscapeString(table.recordType) + "</p>\n")
+		sb.WriteString("<table>\n<thead><tr>")
+		for _, header := range dictionaryHeaders {
+			sb.WriteString("<th>" + header + "</th>")
+		}
+		sb.WriteString("</tr></thead>\n<tbody>\n")
+		for _, row := range table.rows {
+			sb.WriteString("<tr>")
+			for _, cell := range row {
+				sb.WriteString("<td>" + strings.ReplaceAll(html.EscapeString(cell), "\n", "<br>") + "</td>")
+			}
+			sb.WriteString("</tr>\n")
+		}
+		sb.WriteString("</tbody>\n</table>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return []byte(sb.String())
+}
+
+// renderDictionaryXlsx 生成Excel工作簿，第一个工作表为概览，其后每张表一个工作表。
+// 按Office Open XML格式直接打包，单元格使用内联字符串
+func renderDictionaryXlsx(d *dictionary) ([]byte, error) {
+	type sheet struct {
+		name string
+		rows [][]string
+		bold map[int]bool // 加粗的行
+	}
+	overview := &sheet{name: "概览", bold: map[int]bool{4: true}}
+	overview.rows = [][]string{
+		{"应用名称", d.appName},
+		{"包名", d.pkg},
+		{"说明", d.appDesc},
+		nil,
+		{"表名", "说明", "记录类型", "字段数"},
+	}
+	sheets := []*sheet{overview}
+	used := map[string]bool{overview.name: true}
+	for _, table := range d.tables {
+		overview.rows = append(overview.rows, []string{table.name, table.comment, table.recordType, strconv.Itoa(len(table.rows))})
+		s := &sheet{name: xlsxSheetName(table.name, used), bold: map[int]bool{4: true}}
+		s.rows = [][]string{
+			{"表名", table.name},
+			{"说明", table.comment},
+			{"记录类型", table.recordType},
+			nil,
+			dictionaryHeaders,
+		}
+		s.rows = append(s.rows, table.rows...)
+		sheets = append(sheets, s)
+	}
+
+	const header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	var contentTypes, workbook, workbookRels strings.Builder
+	var worksheets []string
+	contentTypes.WriteString(header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, s := range sheets {
+		n := strconv.Itoa(i + 1)
+		contentTypes.WriteString(`<Override PartName="/xl/worksheets/sheet` + n + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		workbook.WriteString(`<sheet name="` + xmlEscape(s.name) + `" sheetId="` + n + `" r:id="rId` + n + `"/>`)
+		workbookRels.WriteString(`<Relationship Id="rId` + n + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + n + `.xml"/>`)
+
+		var ws strings.Builder
+		ws.WriteString(header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+		ws.WriteString(`<cols><col min="1" max="2" width="20" customWidth="1"/><col min="3" max="10" width="14" customWidth="1"/></cols><sheetData>`)
+		for r, row := range s.rows {
+			ref := strconv.Itoa(r + 1)
+			ws.WriteString(`<row r="` + ref + `">`)
+			for c, cell := range row {
+				ws.WriteString(`<c r="` + xlsxColumn(c) + ref + `" t="inlineStr"`)
+				if s.bold[r] || (c == 0 && r < 3) {
+					ws.WriteString(` s="1"`)
+				}
+				ws.WriteString(`><is><t xml:space="preserve">` + xmlEscape(cell) + `</t></is></c>`)
+			}
+			ws.WriteString(`</row>`)
+		}
+		ws.WriteString(`</sheetData></worksheet>`)
+		worksheets = append(worksheets, ws.String())
+	}
+	stylesId := strconv.Itoa(len(sheets) + 1)
+	workbookRels.WriteString(`<Relationship Id="rId` + stylesId + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", header + xlsxStyles},
+	}
+	for i, ws := range worksheets {
+		files = append(files, struct {
+			name    string
+			content string
+		}{"xl/worksheets/sheet" + strconv.Itoa(i+1) + ".xml", ws})
+	}
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write([]byte(f.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xlsxStyles 样式0为默认，样式1为加粗
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// xlsxColumn 列序号(从0开始)对应的列名，如 0 -> A, 26 -> AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxSheetName 工作表名不能包含 []:*?/\ ，最长31个字符且不能重复
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	result := name
+	for n := 2; used[strings.ToLower(result)]; n++ {
+		suffix := "(" + strconv.Itoa(n) + ")"
+		r := []rune(name)
+		if len(r)+len(suffix) > 31 {
+			r = r[:31-len(suffix)]
+		}
+		result = string(r) + suffix
+	}
+	used[strings.ToLower(result)] = true
+	return result
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+	"xorm.io/xorm"
+
+	"github.com/yockii/quick-system/internal/ddl"
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var DdlImportService = new(ddlImportService)
+
+type ddlImportService struct{}
+
+// ImportFromScript 解析建表脚本并转换为应用的表及字段配置，无法映射的内容在对应语句的报告中返回
+func (s *ddlImportService) ImportFromScript(req *model.DdlImportRequest) (*model.DdlImportResult, error) {
+	if req.ApplicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	if strings.TrimSpace(req.Script) == "" {
+		return nil, errors.New("建表脚本不能为空")
+	}
+	if exist, err := database.DB.Exist(&model.Application{Id: req.ApplicationId}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+
+	script := ddl.Parse(req.Script)
+	result := &model.DdlImportResult{
+		Tables:     make([]*model.TableSnapshot, 0, len(script.Tables)),
+		Statements: make([]*model.DdlStatementReport, 0, len(script.Statements)),
+	}
+	// 建表语句与报告对应，映射过程中的问题追加到该语句的报告中
+	createReports := make(map[string]*model.DdlStatementReport)
+	for _, stmt := range script.Statements {
+		report := &model.DdlStatementReport{
+			Index:     stmt.Index,
+			Statement: stmt.Text,
+			Table:     stmt.Table,
+			Messages:  stmt.Messages,
+		}
+		switch {
+		case stmt.Kind == ddl.StatementIgnored:
+			report.Status = model.DdlStatementIgnored
+		case req.Preview:
+			report.Status = model.DdlStatementPreview
+		default:
+			report.Status = model.DdlStatementImported
+		}
+		if stmt.Kind == ddl.StatementCreateTable {
+			createReports[strings.ToLower(stmt.Table)] = report
+		}
+		result.Statements = append(result.Statements, report)
+	}
+
+	tables := make([]*model.TableSnapshot, 0, len(script.Tables))
+	reports := make([]*model.DdlStatementReport, 0, len(script.Tables))
+	for _, t := range script.Tables {
+		report := createReports[strings.ToLower(t.Name)]
+		table, messages := tableSnapshotFromDdl(req.ApplicationId, t)
+		if report != nil {
+			report.Messages = append(report.Messages, messages...)
+		}
+		tables = append(tables, table)
+		reports = append(reports, report)
+	}
+	if req.Preview {
+		result.Tables = append(result.Tables, tables...)
+		return result, nil
+	}
+	if err := s.save(req.ApplicationId, tables, reports, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// save 在同一事务中写入全部表及字段配置，任何一步失败时全部回滚
+func (s *ddlImportService) save(applicationId string, tables []*model.TableSnapshot, reports []*model.DdlStatementReport, result *model.DdlImportResult) error {
+	// 事务失败时报告不应包含已回滚的内容，先收集，提交后再写入
+	saved := make([]*model.TableSnapshot, 0, len(tables))
+	messages := make(map[*model.DdlStatementReport][]string)
+	skipped := make(map[*model.DdlStatementReport]bool)
+	_, err := database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		if err := requireApplicationIn(session, applicationId); err != nil {
+			return nil, err
+		}
+		for i, table := range tables {
+			imported, msgs, err := saveImportedTable(session, applicationId, table)
+			if err != nil {
+				return nil, err
+			}
+			if report := reports[i]; report != nil {
+				if !imported {
+					skipped[report] = true
+					msgs = append(msgs, "应用中已存在同名表")
+				}
+				messages[report] = append(messages[report], msgs...)
+			}
+			if imported {
+				saved = append(saved, table)
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	for report, msgs := range messages {
+		if skipped[report] {
+			report.Status = model.DdlStatementSkipped
+		}
+		report.Messages = append(report.Messages, msgs...)
+	}
+	result.Tables = append(result.Tables, saved...)
+	return nil
+}
+
+func tableSnapshotFromDdl(applicationId string, t *ddl.Table) (*model.TableSnapshot, []string) {
+	var messages []string
+	table := &model.TableSnapshot{
+		TableConfig: &model.TableConfig{
+			ApplicationId: applicationId,
+			TableName:     t.Name,
+			TableComment:  t.Comment,
+		},
+	}
+
+	// 唯一键转换为唯一性校验分组，非id主键同样作为一组唯一性校验
+	keys := t.UniqueKeys
+	if len(t.PrimaryKey) > 0 && !(len(t.PrimaryKey) == 1 && strings.EqualFold(t.PrimaryKey[0], "id")) {
+		keys = append([][]string{t.PrimaryKey}, keys...)
+		messages = append(messages, fmt.Sprintf("%s: 主键(%s)转换为唯一性校验", t.Name, strings.Join(t.PrimaryKey, ",")))
+	}
+	uniqueGroups := make(map[string]int)
+	for i, key := range keys {
+		for _, col := range key {
+			col = strings.ToLower(col)
+			if _, ok := uniqueGroups[col]; ok {
+				messages = append(messages, fmt.Sprintf("%s.%s: 字段属于多个唯一键，仅保留第一个", t.Name, col))
+				continue
+			}
+			uniqueGroups[col] = i + 1
+		}
+	}
+
+	for _, c := range t.Columns {
+		name := strings.ToLower(c.Name)
+		if rt, ok := recordTimeColumns[name]; ok {
+			table.RecordType |= rt
+			continue
+		}
+		if c.PrimaryKey && name == "id" {
+			continue
+		}
+		column := &model.ColumnConfig{
+			ApplicationId: applicationId,
+			ColumnName:    c.Name,
+			DisplayName:   c.Comment,
+			ColumnComment: c.Comment,
+			UniqueCheck:   uniqueGroups[name],
+		}
+		if column.DisplayName == "" {
+			column.DisplayName = c.Name
+		}
+		if !setColumnType(column, c.Type, c.Length, c.Length2) {
+			messages = append(messages, fmt.Sprintf("%s.%s: 无法识别的类型%s，已按字符串导入", t.Name, c.Name, c.Type))
+		}
+		if len(c.EnumValues) > 0 {
+			column.EnumJson = strings.Join(c.EnumValues, ",")
+		}
+		if c.AutoIncrement {
+			messages = append(messages, fmt.Sprintf("%s.%s: 自增属性未导入", t.Name, c.Name))
+		}
+		var mapped bool
+		column.ZeroValue, mapped = zeroValueOf(!c.NotNull, c.Default, c.HasDefault && c.DefaultIsExpr)
+		if !mapped {
+			messages = append(messages, fmt.Sprintf("%s.%s: 默认值表达式%s无法映射", t.Name, c.Name, c.Default))
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	return table, messages
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var ErDiagramService = new(erDiagramService)
+
+type erDiagramService struct{}
+
+type erColumn struct {
+	name     string
+	typeName string // 类型名，用于Mermaid
+	sqlType  string
+	notNull  bool
+	pk       bool
+	fk       bool
+	uk       bool
+	comment  string
+}
+
+type erTable struct {
+	name    string
+	comment string
+	columns []*erColumn
+}
+
+type erRelation struct {
+	name         string
+	source       *erTable
+	target       *erTable
+	sourceColumn string
+	cardinality  int
+	required     bool // 源字段不允许为空
+}
+
+// Render 按表及字段设计生成应用的ER图文本
+func (s *erDiagramService) Render(applicationId string, options *model.ErDiagramOptions) (string, error) {
+	if applicationId == "" {
+		return "", errors.New("应用ID不能为空")
+	}
+	if exist, err := database.DB.Exist(&model.Application{Id: applicationId}); err != nil {
+		return "", err
+	} else if !exist {
+		return "", errors.New("ID所指向的应用不存在")
+	}
+	tables, err := TableConfigService.ListWithColumns(applicationId)
+	if err != nil {
+		return "", err
+	}
+	relations, err := TableRelationService.ListByApplication(applicationId)
+	if err != nil {
+		return "", err
+	}
+	ets, ers, err := buildErModel(tables, relations, options)
+	if err != nil {
+		return "", err
+	}
+	switch options.Format {
+	case model.ErFormatMermaid, "":
+		return renderErMermaid(ets, ers), nil
+	case model.ErFormatPlantUml:
+		return renderErPlantUml(ets, ers), nil
+	case model.ErFormatDot:
+		return renderErDot(ets, ers), nil
+	}
+	return "", fmt.Errorf("不支持的ER图格式%s", options.Format)
+}
+
+// buildErModel 筛选表并整理字段及关联，关联的两端都在所选的表中才会保留
+func buildErModel(tables []*model.TableSnapshot, relations []*model.TableRelation, options *model.ErDiagramOptions) ([]*erTable, []*erRelation, error) {
+	selected := make(map[string]bool)
+	for _, t := range options.Tables {
+		if t = strings.TrimSpace(t); t != "" {
+			selected[t] = true
+		}
+	}
+	fkColumns := make(map[string]bool)
+	for _, relation := range relations {
+		if relation.SourceColumnId != "" {
+			fkColumns[relation.SourceColumnId] = true
+		}
+	}
+
+	var ets []*erTable
+	tableMap := make(map[string]*erTable)
+	columnMap := make(map[string]*model.ColumnConfig)
+	found := make(map[string]bool)
+	for _, table := range tables {
+		if len(selected) > 0 {
+			if selected[table.Id] {
+				found[table.Id] = true
+			} else if selected[table.TableName] {
+				found[table.TableName] = true
+			} else {
+				continue
+			}
+		}
+		et := &erTable{name: table.TableName, comment: table.TableComment}
+		et.columns = append(et.columns, &erColumn{name: "id", typeName: "string", sqlType: "varchar(50)", notNull: true, pk: true})
+		for _, column := range table.Columns {
+			name := strings.ToLower(column.ColumnName)
+			if _, ok := recordTimeColumns[name]; ok || name == "id" {
+				continue
+			}
+			columnMap[column.Id] = column
+			typeName := "string"
+			if spec := model.GetColumnTypeSpec(column.ColumnType); spec != nil {
+				typeName = spec.Name
+			}
+			comment := column.ColumnComment
+			if comment == "" {
+				comment = column.DisplayName
+			}
+			et.columns = append(et.columns, &erColumn{
+				name:     column.ColumnName,
+				typeName: typeName,
+				sqlType:  columnSqlType(column),
+				notNull:  column.ZeroValue == model.ZeroValueNotNull,
+				fk:       fkColumns[column.Id],
+				uk:       column.UniqueCheck > 0,
+				comment:  comment,
+			})
+		}
+		if !options.HideAudit {
+			for _, rt := range []struct {
+				bit     int
+				name    string
+				comment string
+			}{
+				{model.RecordTypeCreateTime, "create_time", "创建时间"},
+				{model.RecordTypeUpdateTime, "update_time", "更新时间"},
+				{model.RecordTypeDeleteTime, "delete_time", "删除时间"},
+			} {
+				if table.RecordType&rt.bit == rt.bit {
+					et.columns = append(et.columns, &erColumn{name: rt.name, typeName: "datetime", sqlType: "datetime", comment: rt.comment})
+				}
+			}
+		}
+		ets = append(ets, et)
+		tableMap[table.Id] = et
+	}
+	for t := range selected {
+		if !found[t] {
+			return nil, nil, fmt.Errorf("表%s不存在或不属于该应用", t)
+		}
+	}
+
+	var ers []*erRelation
+	for _, relation := range relations {
+		source, target := tableMap[relation.SourceTableId], tableMap[relation.TargetTableId]
+		if source == nil || target == nil {
+			continue
+		}
+		er := &erRelation{
+			name:        relation.RelationName,
+			source:      source,
+			target:      target,
+			cardinality: relation.Cardinality,
+		}
+		if column, ok := columnMap[relation.SourceColumnId]; ok {
+			er.sourceColumn = column.ColumnName
+			er.required = column.ZeroValue == model.ZeroValueNotNull
+		}
+		ers = append(ers, er)
+	}
+	return ets, ers, nil
+}
+
+func erQuote(s string) string {
+	return strings.ReplaceAll(s, `"`, `'`)
+}
+
+func renderErMermaid(tables []*erTable, relations []*erRelation) string {
+	var sb strings.Builder
+	sb.WriteString("erDiagram\n")
+	for _, table := range tables {
+		sb.WriteString("    " + table.name + " {\n")
+		for _, column := range table.columns {
+			var keys []string
+			if column.pk {
+				keys = append(keys, "PK")
+			}
+			if column.fk {
+				keys = append(keys, "FK")
+			}
+			if column.uk {
+				keys = append(keys, "UK")
+			}
+			line := "        " + column.typeName + " " + column.name
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ",")
+			}
+			comment := "NULL"
+			if column.notNull {
+				comment = "NOT NULL"
+			}
+			if column.comment != "" {
+				comment += " " + column.comment
+			}
+			sb.WriteString(line + ` "` + erQuote(comment) + "\"\n")
+		}
+		sb.WriteString("    }\n")
+	}
+	for _, relation := range relations {
+		label := relation.name
+		if label == "" {
+			label = relation.sourceColumn
+		}
+		one := "|o"
+		if relation.required {
+			one = "||"
+		}
+		switch relation.cardinality {
+		case model.RelationManyToOne:
+			sb.WriteString(fmt.Sprintf("    %s %s--o{ %s : \"%s\"\n", relation.target.name, one, relation.source.name, erQuote(label)))
+		case model.RelationOneToMany:
+			sb.WriteString(fmt.Sprintf("    %s ||--o{ %s : \"%s\"\n", relation.source.name, relation.target.name, erQuote(label)))
+		default:
+			sb.WriteString(fmt.Sprintf("    %s }o--o{ %s : \"%s\"\n", relation.source.name, relation.target.name, erQuote(label)))
+		}
+	}
+	return sb.String()
+}
+
+func renderErPlantUml(tables []*erTable, relations []*erRelation) string {
+	var sb strings.Builder
+	sb.WriteString("@startuml\nhide circle\nskinparam linetype ortho\n\n")
+	for _, table := range tables {
+		title := table.name
+		if table.comment != "" {
+			title += "\\n" + table.comment
+		}
+		sb.WriteString("entity \"" + erQuote(title) + "\" as " + table.name + " {\n")
+		for i, column := range table.columns {
+			line := "  "
+			if column.notNull {
+				line += "* "
+			}
+			line += column.name + " : " + column.sqlType
+			if column.pk {
+				line += " <<PK>>"
+			}
+			if column.fk {
+				line += " <<FK>>"
+			}
+			if column.uk {
+				line += " <<UK>>"
+			}
+			if column.comment != "" {
+				line += " // " + column.comment
+			}
+			sb.WriteString(line + "\n")
+			if i == 0 && column.pk {
+				sb.WriteString("  --\n")
+			}
+		}
+		sb.WriteString("}\n\n")
+	}
+	for _, relation := range relations {
+		label := relation.name
+		if label == "" {
+			label = relation.sourceColumn
+		}
+		one := "|o"
+		if relation.required {
+			one = "||"
+		}
+		switch relation.cardinality {
+		case model.RelationManyToOne:
+			sb.WriteString(fmt.Sprintf("%s }o--%s %s : %s\n", relation.source.name, one, relation.target.name, label))
+		case model.RelationOneToMany:
+			sb.WriteString(fmt.Sprintf("%s ||--o{ %s : %s\n", relation.source.name, relation.target.name, label))
+		default:
+			sb.WriteString(fmt.Sprintf("%s }o--o{ %s : %s\n", relation.source.name, relation.target.name, label))
+		}
+	}
+	sb.WriteString("@enduml\n")
+	return sb.String()
+}
+
+func renderErDot(tables []*erTable, relations []*erRelation) string {
+	var sb strings.Builder
+	sb.WriteString("digraph er {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=plaintext, fontname=\"Helvetica\"];\n")
+	sb.WriteString("  edge [fontname=\"Helvetica\", fontsize=10, dir=both];\n\n")
+	for _, table := range tables {
+		title := "<b>" + html.EscapeString(table.name) + "</b>"
+		if table.comment != "" {
+			title += "<br/>" + html.EscapeString(table.comment)
+		}
+		sb.WriteString("  \"" + table.name + "\" [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n")
+		sb.WriteString("    <tr><td bgcolor=\"lightgrey\" colspan=\"2\">" + title + "</td></tr>\n")
+		for _, column := range table.columns {
+			name := html.EscapeString(column.name)
+			var keys []string
+			if column.pk {
+				keys = append(keys, "PK")
+			}
+			if column.fk {
+				keys = append(keys, "FK")
+			}
+			if column.uk {
+				keys = append(keys, "UK")
+			}
+			if len(keys) > 0 {
+				name += " (" + strings.Join(keys, ",") + ")"
+			}
+			if column.pk {
+				name = "<u>" + name + "</u>"
+			}
+			sqlType := html.EscapeString(column.sqlType)
+			if column.notNull {
+				sqlType += " NOT NULL"
+			}
+			sb.WriteString("    <tr><td port=\"" + html.EscapeString(column.name) + "\" align=\"left\">" + name + "</td><td align=\"left\">" + sqlType + "</td></tr>\n")
+		}
+		sb.WriteString("  </table>>];\n")
+	}
+	if len(relations) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, relation := range relations {
+		label := relation.name
+		if label == "" {
+			label = relation.sourceColumn
+		}
+		from := "\"" + relation.source.name + "\""
+		if relation.sourceColumn != "" {
+			from += ":\"" + relation.sourceColumn + "\""
+		}
+		to := "\"" + relation.target.name + "\":\"id\""
+		var tail, head string
+		switch relation.cardinality {
+		case model.RelationManyToOne:
+			tail, head = "crow", "tee"
+			if !relation.required {
+				head = "teeodot"
+			}
+		case model.RelationOneToMany:
+			tail, head = "tee", "crow"
+		default:
+			tail, head = "crow", "crow"
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s [label=\"%s\", arrowtail=%s, arrowhead=%s];\n", from, to, erQuote(label), tail, head))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
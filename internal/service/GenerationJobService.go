@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/logger"
+	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+const (
+	defaultGenerationWorkers   = 2
+	generationJobQueueCapacity = 100
+)
+
+var GenerationJobService = &generationJobService{
+	queue:   make(chan string, generationJobQueueCapacity),
+	cancels: make(map[string]context.CancelFunc),
+	locks:   make(map[string]*applicationLock),
+}
+
+type generationJobService struct {
+	queue   chan string
+	once    sync.Once
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	locks   map[string]*applicationLock
+}
+
+// applicationLock 应用的生成锁，持有期间该应用的任务暂存在pending中，不占用工作协程
+type applicationLock struct {
+	held    bool
+	waiters []chan struct{}
+	pending []string
+}
+
+// Start 启动代码生成工作池，并处理服务重启前未完成的任务
+func (s *generationJobService) Start(workers int) {
+	s.once.Do(func() {
+		if workers <= 0 {
+			workers = defaultGenerationWorkers
+		}
+		for i := 0; i < workers; i++ {
+			go s.work()
+		}
+		s.recover()
+	})
+}
+
+// recover 执行中的任务已无法继续，标记为失败；排队中的任务重新入队
+func (s *generationJobService) recover() {
+	_, err := database.DB.Where("status = ?", model.GenerationJobStatusRunning).Update(&model.GenerationJob{
+		Status:   model.GenerationJobStatusFailed,
+		ErrorMsg: "服务重启，任务中断",
+	})
+	if err != nil {
+		logger.Error(err)
+	}
+	var queued []*model.GenerationJob
+	if err = database.DB.Cols("id").Asc("create_time").Find(&queued, &model.GenerationJob{Status: model.GenerationJobStatusQueued}); err != nil {
+		logger.Error(err)
+		return
+	}
+	go func() {
+		for _, job := range queued {
+			s.queue <- job.Id
+		}
+	}()
+}
+
+// Submit 提交代码生成任务，设计校验存在错误且未强制生成时不提交，返回校验结果
+func (s *generationJobService) Submit(applicationId string, releaseNote string, force bool) (*model.GenerationJob, *model.LintResult, error) {
+	if applicationId == "" {
+		return nil, nil, errors.New("应用ID不能为空")
+	}
+	lint, err := LintService.Lint(applicationId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if lint.Errors > 0 && !force {
+		return nil, lint, nil
+	}
+	job, err := s.enqueue(applicationId, releaseNote)
+	return job, lint, err
+}
+
+func (s *generationJobService) enqueue(applicationId string, releaseNote string) (*model.GenerationJob, error) {
+	job := &model.GenerationJob{
+		Id:            model.GenerationJobIdPrefix + util.GenerateDatabaseID(),
+		ApplicationId: applicationId,
+		Status:        model.GenerationJobStatusQueued,
+		ReleaseNote:   releaseNote,
+	}
+	if _, err := database.DB.Insert(job); err != nil {
+		return nil, err
+	}
+	select {
+	case s.queue <- job.Id:
+	default:
+		job.Status = model.GenerationJobStatusFailed
+		job.ErrorMsg = "任务队列已满"
+		if _, err := database.DB.ID(job.Id).Update(&model.GenerationJob{
+			Status:   job.Status,
+			ErrorMsg: job.ErrorMsg,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+// Cancel 取消排队中或执行中的任务。
+// 执行中的任务只在生成、校验等阶段之间响应取消，正在进行的生成器调用无法中断，其结果会被丢弃
+func (s *generationJobService) Cancel(id string) (bool, error) {
+	if id == "" {
+		return false, errors.New("ID不能为空")
+	}
+	c, err := database.DB.Where("id = ?", id).
+		In("status", model.GenerationJobStatusQueued, model.GenerationJobStatusRunning).
+		Update(&model.GenerationJob{Status: model.GenerationJobStatusCanceled})
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	if cancel, ok := s.cancels[id]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+	return c > 0, nil
+}
+
+func (s *generationJobService) Get(instance *model.GenerationJob) (*model.GenerationJob, error) {
+	if instance.Id == "" {
+		return nil, errors.New("ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+// ListByApplication 列出应用的代码生成任务，最新的在前
+func (s *generationJobService) ListByApplication(applicationId string) ([]*model.GenerationJob, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	var list []*model.GenerationJob
+	if err := database.DB.Desc("create_time").Find(&list, &model.GenerationJob{ApplicationId: applicationId}); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *generationJobService) work() {
+	for id := range s.queue {
+		s.run(id)
+	}
+}
+
+func (s *generationJobService) run(id string) {
+	job := new(model.GenerationJob)
+	if has, err := database.DB.ID(id).Get(job); err != nil {
+		logger.Error(err)
+		return
+	} else if !has {
+		return
+	}
+
+	// 同一应用的任务逐个执行，避免并发生成时分配到相同的版本号；
+	// 应用正忙时任务暂存，释放锁后重新入队
+	unlock, ok := s.tryLockApplication(job.ApplicationId, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.mu.Lock()
+	s.cancels[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, id)
+		s.mu.Unlock()
+	}()
+
+	// 仅排队中的任务可以开始执行，已取消的任务直接跳过
+	c, err := database.DB.Where("id = ? and status = ?", id, model.GenerationJobStatusQueued).
+		Update(&model.GenerationJob{Status: model.GenerationJobStatusRunning})
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	if c == 0 {
+		return
+	}
+
+	source, genErr := ApplicationService.GenerateCode(ctx, job.ApplicationId, job.ReleaseNote)
+	if ctx.Err() != nil {
+		// 已由Cancel标记为取消
+		return
+	}
+	if genErr != nil {
+		logger.Error(genErr)
+	}
+	if err = s.finish(job, source, genErr); err != nil {
+		logger.Error(err)
+		// 入库失败，任务不能停留在执行中
+		if _, err = database.DB.Where("id = ? and status = ?", id, model.GenerationJobStatusRunning).Update(&model.GenerationJob{
+			Status:   model.GenerationJobStatusFailed,
+			ErrorMsg: err.Error(),
+		}); err != nil {
+			logger.Error(err)
+		}
+	}
+}
+
+// finish 在同一事务中确认任务仍在执行、应用仍然存在后再保存源码包并记录任务结果。
+// 任务记录加锁后Cancel需等待事务结束，取消与入库不会交错
+func (s *generationJobService) finish(job *model.GenerationJob, source *model.ApplicationSource, genErr error) error {
+	_, err := database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		current := new(model.GenerationJob)
+		if has, err := session.ID(job.Id).ForUpdate().Get(current); err != nil {
+			return nil, err
+		} else if !has || current.Status != model.GenerationJobStatusRunning {
+			return nil, nil
+		}
+		result := new(model.GenerationJob)
+		if genErr != nil {
+			result.Status = model.GenerationJobStatusFailed
+			result.ErrorMsg = genErr.Error()
+		} else if exist, err := session.Exist(&model.Application{Id: job.ApplicationId}); err != nil {
+			return nil, err
+		} else if !exist {
+			result.Status = model.GenerationJobStatusFailed
+			result.ErrorMsg = "应用已被删除"
+		} else {
+			if err = ApplicationSourceService.insertNextVersion(session, source); err != nil {
+				return nil, err
+			}
+			result.SourceId = source.Id
+			result.CheckResult = source.CheckResult
+			if source.Broken {
+				result.Status = model.GenerationJobStatusFailed
+				result.ErrorMsg = sourceCheckMessage(source.CheckResult)
+			} else {
+				result.Status = model.GenerationJobStatusSucceeded
+			}
+		}
+		_, err := session.ID(job.Id).Update(result)
+		return nil, err
+	})
+	return err
+}
+
+// lockApplication 获取应用的生成锁，锁被占用时等待，返回释放函数
+func (s *generationJobService) lockApplication(applicationId string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[applicationId]
+	if !ok {
+		l = new(applicationLock)
+		s.locks[applicationId] = l
+	}
+	if !l.held {
+		l.held = true
+		s.mu.Unlock()
+		return s.releaser(applicationId)
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	s.mu.Unlock()
+	// 释放时锁直接移交给等待者
+	<-ready
+	return s.releaser(applicationId)
+}
+
+// tryLockApplication 尝试获取应用的生成锁，锁被占用时暂存任务ID并返回false
+func (s *generationJobService) tryLockApplication(applicationId string, jobId string) (func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.locks[applicationId]
+	if !ok {
+		l = new(applicationLock)
+		s.locks[applicationId] = l
+	}
+	if l.held {
+		l.pending = append(l.pending, jobId)
+		return nil, false
+	}
+	l.held = true
+	return s.releaser(applicationId), true
+}
+
+// releaser 返回应用生成锁的释放函数，优先移交给等待者，否则将暂存的任务重新入队
+func (s *generationJobService) releaser(applicationId string) func() {
+	return func() {
+		s.mu.Lock()
+		l := s.locks[applicationId]
+		if len(l.waiters) > 0 {
+			ready := l.waiters[0]
+			l.waiters = l.waiters[1:]
+			s.mu.Unlock()
+			close(ready)
+			return
+		}
+		pending := l.pending
+		delete(s.locks, applicationId)
+		s.mu.Unlock()
+		if len(pending) > 0 {
+			go func() {
+				for _, id := range pending {
+					s.queue <- id
+				}
+			}()
+		}
+	}
+}
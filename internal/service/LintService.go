@@ -0,0 +1,215 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var LintService = new(lintService)
+
+type lintService struct{}
+
+// 校验规则ID
+const (
+	LintRuleInvalidName     = "invalid-name"
+	LintRuleNameStyle       = "name-style"
+	LintRuleNameTooLong     = "name-too-long"
+	LintRuleReservedWord    = "reserved-word"
+	LintRuleDuplicateTable  = "duplicate-table"
+	LintRuleDuplicateColumn = "duplicate-column"
+	LintRuleGeneratedColumn = "generated-column"
+	LintRuleEmptyTable      = "empty-table"
+	LintRuleInvalidEnum     = "invalid-enum"
+	LintRuleDecimalLength   = "decimal-length"
+	LintRuleStringLength    = "string-length"
+	LintRuleInvalidType     = "invalid-type"
+)
+
+const maxNameLength = 64
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	snakeCasePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// 常见数据库(MySQL/PostgreSQL)的保留字
+var sqlReservedWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`add all alter analyze and as asc between both by call case cast check collate column
+		condition constraint create cross current_date current_time current_timestamp current_user cursor database
+		default delete desc describe distinct drop else end exists explain false fetch for foreign from full grant group
+		having in index inner insert interval into is join key keys kill leading left like limit lock match natural not
+		null offset on only option or order outer partition primary procedure range read references rename replace
+		return revoke right row rows select session_user set show table then to trailing trigger true union unique
+		update usage user using values view when where window with write`) {
+		sqlReservedWords[w] = true
+	}
+}
+
+// Lint 校验应用的表及字段设计
+func (s *lintService) Lint(applicationId string) (*model.LintResult, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	if exist, err := database.DB.Exist(&model.Application{Id: applicationId}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+	tables, err := TableConfigService.ListWithColumns(applicationId)
+	if err != nil {
+		return nil, err
+	}
+	return lintTables(tables), nil
+}
+
+type linter struct {
+	result *model.LintResult
+}
+
+func (l *linter) report(level, rule string, table *model.TableConfig, column *model.ColumnConfig, format string, args ...interface{}) {
+	issue := &model.LintIssue{
+		Level:   level,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	}
+	if table != nil {
+		issue.TableId = table.Id
+		issue.TableName = table.TableName
+	}
+	if column != nil {
+		issue.ColumnId = column.Id
+		issue.ColumnName = column.ColumnName
+	}
+	if level == model.LintLevelError {
+		l.result.Errors++
+	} else {
+		l.result.Warnings++
+	}
+	l.result.Issues = append(l.result.Issues, issue)
+}
+
+func (l *linter) checkName(table *model.TableConfig, column *model.ColumnConfig, kind, name string) {
+	if !identifierPattern.MatchString(name) {
+		l.report(model.LintLevelError, LintRuleInvalidName, table, column, "%s名%s只能包含字母、数字及下划线，且不能以数字开头", kind, name)
+		return
+	}
+	if len(name) > maxNameLength {
+		l.report(model.LintLevelError, LintRuleNameTooLong, table, column, "%s名%s超过%d个字符", kind, name, maxNameLength)
+	}
+	if sqlReservedWords[strings.ToLower(name)] {
+		l.report(model.LintLevelError, LintRuleReservedWord, table, column, "%s名%s是SQL保留字", kind, name)
+	}
+	if !snakeCasePattern.MatchString(name) {
+		l.report(model.LintLevelWarning, LintRuleNameStyle, table, column, "%s名%s建议使用小写下划线风格", kind, name)
+	}
+}
+
+func lintTables(tables []*model.TableSnapshot) *model.LintResult {
+	l := &linter{result: &model.LintResult{Issues: make([]*model.LintIssue, 0)}}
+	tableNames := make(map[string]bool)
+	for _, table := range tables {
+		l.checkName(table.TableConfig, nil, "表", table.TableName)
+		key := strings.ToLower(table.TableName)
+		if tableNames[key] {
+			l.report(model.LintLevelError, LintRuleDuplicateTable, table.TableConfig, nil, "表名%s重复", table.TableName)
+		}
+		tableNames[key] = true
+		if len(table.Columns) == 0 {
+			l.report(model.LintLevelError, LintRuleEmptyTable, table.TableConfig, nil, "表%s没有任何字段", table.TableName)
+		}
+
+		columnNames := make(map[string]bool)
+		for _, column := range table.Columns {
+			l.checkName(table.TableConfig, column, "字段", column.ColumnName)
+			key := strings.ToLower(column.ColumnName)
+			if columnNames[key] {
+				l.report(model.LintLevelError, LintRuleDuplicateColumn, table.TableConfig, column, "字段名%s重复", column.ColumnName)
+			}
+			columnNames[key] = true
+			if key == "id" {
+				l.report(model.LintLevelError, LintRuleGeneratedColumn, table.TableConfig, column, "字段id由生成器自动生成，不能重复定义")
+			} else if rt, ok := recordTimeColumns[key]; ok && table.RecordType&rt == rt {
+				l.report(model.LintLevelError, LintRuleGeneratedColumn, table.TableConfig, column, "表已设置记录%s，字段%s由生成器自动生成", column.ColumnName, column.ColumnName)
+			}
+			l.checkColumn(table.TableConfig, column)
+		}
+	}
+	return l.result
+}
+
+func (l *linter) checkColumn(table *model.TableConfig, column *model.ColumnConfig) {
+	if column.ColumnType != 0 && model.GetColumnTypeSpec(column.ColumnType) == nil {
+		l.report(model.LintLevelError, LintRuleInvalidType, table, column, "未知的字段类型%d", column.ColumnType)
+		return
+	}
+	switch column.ColumnType {
+	case model.ColumnTypeDecimal:
+		if column.DecimalLength > 0 && column.ColumnLength <= 0 {
+			l.report(model.LintLevelError, LintRuleDecimalLength, table, column, "设置了小数位数时必须设置字段长度")
+		} else if column.ColumnLength > 0 && column.DecimalLength >= column.ColumnLength {
+			l.report(model.LintLevelError, LintRuleDecimalLength, table, column, "小数部分长度%d必须小于字段长度%d", column.DecimalLength, column.ColumnLength)
+		}
+	case model.ColumnTypeEnum:
+		if strings.TrimSpace(column.EnumJson) == "" {
+			l.report(model.LintLevelError, LintRuleInvalidEnum, table, column, "枚举类型的字段必须设置枚举值")
+		}
+	case model.ColumnTypeString, 0:
+		if column.StringType != model.StringTypeLongtext {
+			if column.ColumnLength <= 0 {
+				l.report(model.LintLevelWarning, LintRuleStringLength, table, column, "字符串字段未设置长度，将使用默认长度")
+			} else if column.ColumnLength > 16383 {
+				l.report(model.LintLevelWarning, LintRuleStringLength, table, column, "varchar长度%d过大，建议使用longtext", column.ColumnLength)
+			}
+		}
+	}
+
+	if column.EnumJson != "" {
+		l.checkEnum(table, column)
+	}
+}
+
+func (l *linter) checkEnum(table *model.TableConfig, column *model.ColumnConfig) {
+	// 字符串类及文本枚举以,分割，int类为 [{"key":1,"value":""}] 格式的json
+	if column.ColumnType == model.ColumnTypeString || column.ColumnType == model.ColumnTypeEnum || column.ColumnType == 0 {
+		for _, v := range strings.Split(column.EnumJson, ",") {
+			if strings.TrimSpace(v) == "" {
+				l.report(model.LintLevelWarning, LintRuleInvalidEnum, table, column, "枚举值中存在空项")
+				return
+			}
+		}
+		return
+	}
+	var items []struct {
+		Key   interface{} `json:"key"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(column.EnumJson), &items); err != nil {
+		l.report(model.LintLevelError, LintRuleInvalidEnum, table, column, "枚举值不是合法的json: %s", err.Error())
+		return
+	}
+	if len(items) == 0 {
+		l.report(model.LintLevelWarning, LintRuleInvalidEnum, table, column, "枚举值为空")
+		return
+	}
+	keys := make(map[float64]bool)
+	for _, item := range items {
+		key, ok := item.Key.(float64)
+		if !ok {
+			l.report(model.LintLevelError, LintRuleInvalidEnum, table, column, "枚举的key必须为数字: %v", item.Key)
+			continue
+		}
+		if keys[key] {
+			l.report(model.LintLevelError, LintRuleInvalidEnum, table, column, "枚举的key %v重复", key)
+		}
+		keys[key] = true
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/logger"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var MaintenanceService = new(maintenanceService)
+
+type maintenanceService struct{}
+
+// PurgeOrphans 查找并清理所属应用或所属表已不存在的孤立记录，dryRun为true时仅统计。
+// 孤立条件以子查询表示，在删除事务中求值，清理期间新写入的孤立记录同样会被删除
+func (s *maintenanceService) PurgeOrphans(dryRun bool) (*model.CascadeDeleteResult, error) {
+	applications := "select id from " + database.DB.TableName(&model.Application{}, true)
+	// 应用不存在的表视为孤立，其字段、索引及关联随之清理
+	validTables := "select id from " + database.DB.TableName(&model.TableConfig{}, true) + " where application_id in (" + applications + ")"
+
+	plan := new(cascadePlan)
+	plan.addWhere("table_relation", &model.TableRelation{},
+		"source_table_id not in ("+validTables+") or target_table_id not in ("+validTables+")")
+	plan.addWhere("table_index", &model.TableIndex{}, "table_id not in ("+validTables+")")
+	plan.addWhere("column_config", &model.ColumnConfig{}, "table_id not in ("+validTables+")")
+	plan.addWhere("table_config", &model.TableConfig{}, "application_id not in ("+applications+")")
+	for _, child := range []struct {
+		name string
+		bean interface{}
+	}{
+		{"application_config", &model.ApplicationConfig{}},
+		{"code_template", &model.CodeTemplate{}},
+		{"generation_job", &model.GenerationJob{}},
+		{"application_source", &model.ApplicationSource{}},
+		{"application_runtime", &model.ApplicationRuntime{}},
+	} {
+		plan.addWhere(child.name, child.bean, "application_id not in ("+applications+")")
+	}
+
+	if dryRun {
+		return plan.preview()
+	}
+	result, err := plan.execute()
+	if err != nil {
+		return nil, err
+	}
+	// 运行时记录已被清理的应用不再提供运行时接口
+	for _, applicationId := range ApplicationRuntimeService.registeredApplicationIds() {
+		if exist, err := database.DB.Exist(&model.ApplicationRuntime{ApplicationId: applicationId}); err != nil {
+			logger.Error(err)
+		} else if !exist {
+			ApplicationRuntimeService.unregister(applicationId)
+		}
+	}
+	return result, nil
+}
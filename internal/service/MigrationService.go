@@ -0,0 +1,819 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var MigrationService = new(migrationService)
+
+type migrationService struct{}
+
+// Generate 生成应用两个设计版本之间的数据库迁移脚本。
+// fromVersion为0时使用最近一次生成的版本，小于0时从空数据库开始；toVersion为0时使用当前设计
+func (s *migrationService) Generate(applicationId string, fromVersion, toVersion int, dialect string) (*model.MigrationScript, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	if dialect == "" {
+		dialect = model.SqlDialectMysql
+	}
+	d := sqlDialectOf(dialect)
+	if d == nil {
+		return nil, fmt.Errorf("不支持的数据库类型%s", dialect)
+	}
+	if exist, err := database.DB.Exist(&model.Application{Id: applicationId}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+
+	var from, to []*model.TableSnapshot
+	if fromVersion == 0 {
+		next, err := ApplicationSourceService.NextVersion(applicationId)
+		if err != nil {
+			return nil, err
+		}
+		fromVersion = next - 1
+	}
+	if fromVersion > 0 {
+		snapshot, err := s.loadSnapshot(applicationId, fromVersion)
+		if err != nil {
+			return nil, err
+		}
+		from = snapshot.Tables
+	} else {
+		fromVersion = 0
+	}
+	if toVersion > 0 {
+		snapshot, err := s.loadSnapshot(applicationId, toVersion)
+		if err != nil {
+			return nil, err
+		}
+		to = snapshot.Tables
+	} else {
+		tables, err := TableConfigService.ListWithColumns(applicationId)
+		if err != nil {
+			return nil, err
+		}
+		to = tables
+		toVersion = 0
+	}
+
+	script := diffSchema(d, from, to)
+	script.Dialect = dialect
+	script.FromVersion = fromVersion
+	script.ToVersion = toVersion
+	return script, nil
+}
+
+func (s *migrationService) loadSnapshot(applicationId string, version int) (*model.ApplicationSnapshot, error) {
+	source := &model.ApplicationSource{ApplicationId: applicationId, Version: version}
+	has, err := database.DB.Cols("snapshot").Get(source)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, fmt.Errorf("版本%d不存在", version)
+	}
+	if source.Snapshot == nil {
+		return nil, fmt.Errorf("版本%d没有保存设计快照", version)
+	}
+	return source.Snapshot, nil
+}
+
+// columnDef 字段在数据库中的定义，key用于在两个版本间匹配同一字段
+type columnDef struct {
+	key          string
+	name         string
+	sqlType      string
+	notNull      bool
+	defaultValue string // SQL字面量，空表示没有默认值
+	comment      string
+	primaryKey   bool
+	config       *model.ColumnConfig // 生成器自动生成的字段为nil
+}
+
+func (c *columnDef) sameAs(o *columnDef) bool {
+	return c.sqlType == o.sqlType && c.notNull == o.notNull && c.defaultValue == o.defaultValue && c.comment == o.comment
+}
+
+// sqlDialect 不同数据库的DDL语法
+type sqlDialect interface {
+	quote(name string) string
+	columnType(column *model.ColumnConfig) string
+	timeType() string
+	literal(column *model.ColumnConfig, value string) string
+	createTable(table string, comment string, columns []*columnDef) []string
+	addColumn(table string, column *columnDef) []string
+	modifyColumn(table string, old, new *columnDef) []string
+	renameTable(old, new string) string
+	tableComment(table, comment string) string
+	createIndex(table string, index *indexDef) (string, string)
+	dropIndex(table, index string) string
+}
+
+func sqlDialectOf(dialect string) sqlDialect {
+	switch dialect {
+	case model.SqlDialectMysql:
+		return mysqlDialect{}
+	case model.SqlDialectPostgres:
+		return postgresDialect{}
+	}
+	return nil
+}
+
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// tableColumnDefs 表在数据库中的全部字段，包括生成器自动生成的id及记录时间字段
+func tableColumnDefs(d sqlDialect, table *model.TableSnapshot) []*columnDef {
+	defs := []*columnDef{{key: "#id", name: "id", sqlType: "varchar(50)", notNull: true, primaryKey: true}}
+	for _, column := range table.Columns {
+		name := strings.ToLower(column.ColumnName)
+		if _, ok := recordTimeColumns[name]; ok || name == "id" {
+			continue
+		}
+		def := &columnDef{
+			key:     column.Id,
+			name:    column.ColumnName,
+			sqlType: d.columnType(column),
+			comment: column.ColumnComment,
+			config:  column,
+		}
+		switch column.ZeroValue {
+		case "":
+		case model.ZeroValueNotNull:
+			def.notNull = true
+		default:
+			// 长文本及json不支持默认值，由生成的代码处理
+			if column.ColumnType != model.ColumnTypeJson && !(column.ColumnType == model.ColumnTypeString && column.StringType == model.StringTypeLongtext) {
+				def.notNull = true
+				def.defaultValue = d.literal(column, column.ZeroValue)
+			}
+		}
+		defs = append(defs, def)
+	}
+	for _, rt := range []struct {
+		name    string
+		bit     int
+		comment string
+	}{
+		{"create_time", model.RecordTypeCreateTime, "创建时间"},
+		{"update_time", model.RecordTypeUpdateTime, "更新时间"},
+		{"delete_time", model.RecordTypeDeleteTime, "删除时间"},
+	} {
+		if table.RecordType&rt.bit == rt.bit {
+			defs = append(defs, &columnDef{key: "#" + rt.name, name: rt.name, sqlType: d.timeType(), comment: rt.comment})
+		}
+	}
+	return defs
+}
+
+// indexDef 以字段名描述的索引
+type indexDef struct {
+	key     string
+	name    string
+	unique  bool
+	columns []string
+	lengths []int
+}
+
+func (i *indexDef) sameAs(o *indexDef) bool {
+	if i.name != o.name || i.unique != o.unique || len(i.columns) != len(o.columns) {
+		return false
+	}
+	for n := range i.columns {
+		if i.columns[n] != o.columns[n] || i.lengths[n] != o.lengths[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func tableIndexDefs(table *model.TableSnapshot) []*indexDef {
+	columnNames := make(map[string]string)
+	for _, column := range table.Columns {
+		columnNames[column.Id] = column.ColumnName
+	}
+	var defs []*indexDef
+	for _, index := range table.Indexes {
+		def := &indexDef{key: index.Id, name: index.IndexName, unique: index.IsUnique == 1}
+		for _, ic := range index.Columns {
+			if name, ok := columnNames[ic.ColumnId]; ok {
+				def.columns = append(def.columns, name)
+				def.lengths = append(def.lengths, ic.PrefixLength)
+			}
+		}
+		if len(def.columns) > 0 {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// migrationBuilder 按阶段收集迁移步骤，保证执行顺序:
+// 删除索引 -> 重命名表 -> 创建表 -> 修改字段 -> 创建索引 -> 删除表
+type migrationBuilder struct {
+	d           sqlDialect
+	dropIndexes []*model.MigrationStep
+	renames     []*model.MigrationStep
+	creates     []*model.MigrationStep
+	alters      []*model.MigrationStep
+	addIndexes  []*model.MigrationStep
+	dropTables  []*model.MigrationStep
+}
+
+func diffSchema(d sqlDialect, from, to []*model.TableSnapshot) *model.MigrationScript {
+	b := &migrationBuilder{d: d}
+	oldKeys, oldNames := make([]string, len(from)), make([]string, len(from))
+	for n, table := range from {
+		oldKeys[n], oldNames[n] = table.Id, table.TableName
+	}
+	newKeys, newNames := make([]string, len(to)), make([]string, len(to))
+	for n, table := range to {
+		newKeys[n], newNames[n] = table.Id, table.TableName
+	}
+	matches := matchByKeyThenName(oldKeys, oldNames, newKeys, newNames)
+	matched := make(map[int]bool)
+	for n, table := range to {
+		o, ok := matches[n]
+		if !ok {
+			b.createTable(table)
+			continue
+		}
+		matched[o] = true
+		b.alterTable(from[o], table)
+	}
+	for o, table := range from {
+		if !matched[o] {
+			b.dropTables = append(b.dropTables, &model.MigrationStep{
+				Action:      model.MigrationDropTable,
+				Table:       table.TableName,
+				Statements:  []string{"DROP TABLE " + d.quote(table.TableName)},
+				Destructive: true,
+				Warning:     fmt.Sprintf("删除表%s将丢失表中全部数据", table.TableName),
+			})
+		}
+	}
+
+	script := &model.MigrationScript{Steps: make([]*model.MigrationStep, 0)}
+	for _, steps := range [][]*model.MigrationStep{b.dropIndexes, b.renames, b.creates, b.alters, b.addIndexes, b.dropTables} {
+		script.Steps = append(script.Steps, steps...)
+	}
+	var sb strings.Builder
+	if _, ok := d.(postgresDialect); ok {
+		sb.WriteString("BEGIN;\n\n")
+	}
+	for _, step := range script.Steps {
+		if step.Destructive {
+			script.Destructive++
+			sb.WriteString("-- 警告: 破坏性操作，" + step.Warning + "\n")
+		} else if step.Warning != "" {
+			sb.WriteString("-- 注意: " + step.Warning + "\n")
+		}
+		for _, statement := range step.Statements {
+			sb.WriteString(statement + ";\n")
+		}
+		sb.WriteString("\n")
+	}
+	if _, ok := d.(postgresDialect); ok {
+		sb.WriteString("COMMIT;\n")
+	}
+	script.Script = sb.String()
+	return script
+}
+
+// matchByKeyThenName 在两个版本间匹配同一对象，返回新项下标 -> 旧项下标。
+// 先按ID匹配全部项以识别重命名，剩余的再按名称匹配，避免改名后复用旧名称的新项抢占原有的项
+func matchByKeyThenName(oldKeys, oldNames, newKeys, newNames []string) map[int]int {
+	matches := make(map[int]int)
+	used := make(map[int]bool)
+	for n, key := range newKeys {
+		if key == "" {
+			continue
+		}
+		for o, oldKey := range oldKeys {
+			if !used[o] && oldKey == key {
+				matches[n] = o
+				used[o] = true
+				break
+			}
+		}
+	}
+	for n, name := range newNames {
+		if _, ok := matches[n]; ok {
+			continue
+		}
+		for o, oldName := range oldNames {
+			if !used[o] && strings.EqualFold(oldName, name) {
+				matches[n] = o
+				used[o] = true
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func (b *migrationBuilder) createTable(table *model.TableSnapshot) {
+	b.creates = append(b.creates, &model.MigrationStep{
+		Action:     model.MigrationCreateTable,
+		Table:      table.TableName,
+		Statements: b.d.createTable(table.TableName, table.TableComment, tableColumnDefs(b.d, table)),
+	})
+	for _, index := range tableIndexDefs(table) {
+		b.createIndex(table.TableName, index)
+	}
+}
+
+func (b *migrationBuilder) createIndex(table string, index *indexDef) {
+	statement, warning := b.d.createIndex(table, index)
+	b.addIndexes = append(b.addIndexes, &model.MigrationStep{
+		Action:     model.MigrationCreateIndex,
+		Table:      table,
+		Index:      index.name,
+		Statements: []string{statement},
+		Warning:    warning,
+	})
+}
+
+func (b *migrationBuilder) alterTable(old, table *model.TableSnapshot) {
+	name := table.TableName
+	if old.TableName != table.TableName {
+		b.renames = append(b.renames, &model.MigrationStep{
+			Action:     model.MigrationRenameTable,
+			Table:      name,
+			Statements: []string{b.d.renameTable(old.TableName, name)},
+		})
+	}
+	if old.TableComment != table.TableComment {
+		b.alters = append(b.alters, &model.MigrationStep{
+			Action:     model.MigrationAlterTable,
+			Table:      name,
+			Statements: []string{b.d.tableComment(name, table.TableComment)},
+		})
+	}
+
+	// 索引以旧表名删除，因此放在重命名之前
+	oldIndexes := tableIndexDefs(old)
+	newIndexes := tableIndexDefs(table)
+	indexMatches := matchByKeyThenName(indexKeys(oldIndexes), indexNames(oldIndexes), indexKeys(newIndexes), indexNames(newIndexes))
+	keptIndexes := make(map[int]bool)
+	for n, index := range newIndexes {
+		if o, ok := indexMatches[n]; ok {
+			match := oldIndexes[o]
+			keptIndexes[o] = true
+			if match.sameAs(index) {
+				continue
+			}
+			b.dropIndex(old.TableName, match.name)
+		}
+		b.createIndex(name, index)
+	}
+	for o, oi := range oldIndexes {
+		if !keptIndexes[o] {
+			b.dropIndex(old.TableName, oi.name)
+		}
+	}
+
+	oldColumns := tableColumnDefs(b.d, old)
+	newColumns := tableColumnDefs(b.d, table)
+	columnMatches := matchByKeyThenName(columnKeys(oldColumns), columnNames(oldColumns), columnKeys(newColumns), columnNames(newColumns))
+	keptColumns := make(map[int]bool)
+	for n, column := range newColumns {
+		o, ok := columnMatches[n]
+		if !ok {
+			step := &model.MigrationStep{
+				Action:     model.MigrationAddColumn,
+				Table:      name,
+				Column:     column.name,
+				Statements: b.d.addColumn(name, column),
+			}
+			if column.notNull && column.defaultValue == "" {
+				step.Warning = fmt.Sprintf("字段%s不允许为空且没有默认值，表中已有数据时可能执行失败", column.name)
+			}
+			b.alters = append(b.alters, step)
+			continue
+		}
+		match := oldColumns[o]
+		keptColumns[o] = true
+		if match.name == column.name && match.sameAs(column) {
+			continue
+		}
+		step := &model.MigrationStep{
+			Action:     model.MigrationModifyColumn,
+			Table:      name,
+			Column:     column.name,
+			Statements: b.d.modifyColumn(name, match, column),
+		}
+		if match.sqlType != column.sqlType && !isSafeTypeChange(match, column) {
+			step.Destructive = true
+			step.Warning = fmt.Sprintf("字段%s的类型由%s改为%s，已有数据可能被截断或转换失败", column.name, match.sqlType, column.sqlType)
+		} else if column.notNull && !match.notNull {
+			step.Warning = fmt.Sprintf("字段%s改为不允许为空，已有空值时可能执行失败", column.name)
+		}
+		b.alters = append(b.alters, step)
+	}
+	for o, oc := range oldColumns {
+		if !keptColumns[o] {
+			b.alters = append(b.alters, &model.MigrationStep{
+				Action:      model.MigrationDropColumn,
+				Table:       name,
+				Column:      oc.name,
+				Statements:  []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", b.d.quote(name), b.d.quote(oc.name))},
+				Destructive: true,
+				Warning:     fmt.Sprintf("删除字段%s.%s将丢失该字段的数据", name, oc.name),
+			})
+		}
+	}
+}
+
+func columnKeys(defs []*columnDef) []string {
+	keys := make([]string, len(defs))
+	for n, def := range defs {
+		keys[n] = def.key
+	}
+	return keys
+}
+
+func columnNames(defs []*columnDef) []string {
+	names := make([]string, len(defs))
+	for n, def := range defs {
+		names[n] = def.name
+	}
+	return names
+}
+
+func indexKeys(defs []*indexDef) []string {
+	keys := make([]string, len(defs))
+	for n, def := range defs {
+		keys[n] = def.key
+	}
+	return keys
+}
+
+func indexNames(defs []*indexDef) []string {
+	names := make([]string, len(defs))
+	for n, def := range defs {
+		names[n] = def.name
+	}
+	return names
+}
+
+func (b *migrationBuilder) dropIndex(table, index string) {
+	b.dropIndexes = append(b.dropIndexes, &model.MigrationStep{
+		Action:     model.MigrationDropIndex,
+		Table:      table,
+		Index:      index,
+		Statements: []string{b.d.dropIndex(table, index)},
+	})
+}
+
+// isSafeTypeChange 判断字段类型的变更是否不会丢失数据，仅识别常见的扩大类型
+func isSafeTypeChange(old, new *columnDef) bool {
+	o, n := old.config, new.config
+	if o == nil || n == nil {
+		return false
+	}
+	switch {
+	case o.ColumnType == n.ColumnType:
+		switch o.ColumnType {
+		case model.ColumnTypeString:
+			if n.StringType == model.StringTypeLongtext {
+				return true
+			}
+			return o.StringType != model.StringTypeLongtext && lengthOr(n.ColumnLength, 255) >= lengthOr(o.ColumnLength, 255)
+		case model.ColumnTypeFile:
+			return lengthOr(n.ColumnLength, 500) >= lengthOr(o.ColumnLength, 500)
+		case model.ColumnTypeDecimal:
+			return n.DecimalLength >= o.DecimalLength &&
+				lengthOr(n.ColumnLength, 10)-n.DecimalLength >= lengthOr(o.ColumnLength, 10)-o.DecimalLength
+		case model.ColumnTypeEnum:
+			values := make(map[string]bool)
+			for _, v := range strings.Split(n.EnumJson, ",") {
+				values[strings.TrimSpace(v)] = true
+			}
+			for _, v := range strings.Split(o.EnumJson, ",") {
+				if v = strings.TrimSpace(v); v != "" && !values[v] {
+					return false
+				}
+			}
+			return true
+		}
+		return true
+	case o.ColumnType == model.ColumnTypeBool:
+		return n.ColumnType == model.ColumnTypeInt || n.ColumnType == model.ColumnTypeBigInt
+	case o.ColumnType == model.ColumnTypeInt:
+		return n.ColumnType == model.ColumnTypeBigInt || n.ColumnType == model.ColumnTypeFloat
+	case o.ColumnType == model.ColumnTypeDate:
+		return n.ColumnType == model.ColumnTypeDateTime
+	case o.ColumnType == model.ColumnTypeEnum:
+		return n.ColumnType == model.ColumnTypeString && n.StringType == model.StringTypeLongtext
+	}
+	return false
+}
+
+func lengthOr(length, defaultLength int) int {
+	if length > 0 {
+		return length
+	}
+	return defaultLength
+}
+
+// numericLiteral 数值类字段的默认值为合法数字时不加引号
+func numericLiteral(column *model.ColumnConfig, value string) (string, bool) {
+	switch column.ColumnType {
+	case model.ColumnTypeInt, model.ColumnTypeBigInt, model.ColumnTypeFloat, model.ColumnTypeDecimal:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func boolValue(value string) bool {
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) columnType(column *model.ColumnConfig) string {
+	return columnSqlType(column)
+}
+
+func (mysqlDialect) timeType() string {
+	return "datetime"
+}
+
+func (mysqlDialect) literal(column *model.ColumnConfig, value string) string {
+	if column.ColumnType == model.ColumnTypeBool {
+		if boolValue(value) {
+			return "1"
+		}
+		return "0"
+	}
+	if v, ok := numericLiteral(column, value); ok {
+		return v
+	}
+	return quoteString(value)
+}
+
+func (d mysqlDialect) definition(column *columnDef) string {
+	def := d.quote(column.name) + " " + column.sqlType
+	if column.notNull {
+		def += " NOT NULL"
+	}
+	if column.defaultValue != "" {
+		def += " DEFAULT " + column.defaultValue
+	}
+	if column.comment != "" {
+		def += " COMMENT " + quoteString(column.comment)
+	}
+	return def
+}
+
+func (d mysqlDialect) createTable(table string, comment string, columns []*columnDef) []string {
+	lines := make([]string, 0, len(columns)+1)
+	var primaryKeys []string
+	for _, column := range columns {
+		lines = append(lines, "  "+d.definition(column))
+		if column.primaryKey {
+			primaryKeys = append(primaryKeys, d.quote(column.name))
+		}
+	}
+	if len(primaryKeys) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(primaryKeys, ", ")+")")
+	}
+	statement := "CREATE TABLE " + d.quote(table) + " (\n" + strings.Join(lines, ",\n") + "\n)"
+	if comment != "" {
+		statement += " COMMENT=" + quoteString(comment)
+	}
+	return []string{statement}
+}
+
+func (d mysqlDialect) addColumn(table string, column *columnDef) []string {
+	return []string{"ALTER TABLE " + d.quote(table) + " ADD COLUMN " + d.definition(column)}
+}
+
+func (d mysqlDialect) modifyColumn(table string, old, new *columnDef) []string {
+	if old.name != new.name {
+		return []string{"ALTER TABLE " + d.quote(table) + " CHANGE COLUMN " + d.quote(old.name) + " " + d.definition(new)}
+	}
+	return []string{"ALTER TABLE " + d.quote(table) + " MODIFY COLUMN " + d.definition(new)}
+}
+
+func (d mysqlDialect) renameTable(old, new string) string {
+	return "ALTER TABLE " + d.quote(old) + " RENAME TO " + d.quote(new)
+}
+
+func (d mysqlDialect) tableComment(table, comment string) string {
+	return "ALTER TABLE " + d.quote(table) + " COMMENT=" + quoteString(comment)
+}
+
+func (d mysqlDialect) createIndex(table string, index *indexDef) (string, string) {
+	columns := make([]string, 0, len(index.columns))
+	for i, column := range index.columns {
+		if index.lengths[i] > 0 {
+			columns = append(columns, fmt.Sprintf("%s(%d)", d.quote(column), index.lengths[i]))
+		} else {
+			columns = append(columns, d.quote(column))
+		}
+	}
+	statement := "CREATE INDEX "
+	if index.unique {
+		statement = "CREATE UNIQUE INDEX "
+	}
+	return statement + d.quote(index.name) + " ON " + d.quote(table) + " (" + strings.Join(columns, ", ") + ")", ""
+}
+
+func (d mysqlDialect) dropIndex(table, index string) string {
+	return "DROP INDEX " + d.quote(index) + " ON " + d.quote(table)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) columnType(column *model.ColumnConfig) string {
+	switch column.ColumnType {
+	case model.ColumnTypeInt:
+		return "integer"
+	case model.ColumnTypeDateTime:
+		return "timestamp"
+	case model.ColumnTypeDecimal:
+		if column.ColumnLength > 0 {
+			return fmt.Sprintf("numeric(%d,%d)", column.ColumnLength, column.DecimalLength)
+		}
+		return "numeric(10,2)"
+	case model.ColumnTypeBool:
+		return "boolean"
+	case model.ColumnTypeBigInt:
+		return "bigint"
+	case model.ColumnTypeFloat:
+		return "double precision"
+	case model.ColumnTypeDate:
+		return "date"
+	case model.ColumnTypeTime:
+		return "time"
+	case model.ColumnTypeJson:
+		return "jsonb"
+	case model.ColumnTypeEnum:
+		length := 50
+		for _, v := range strings.Split(column.EnumJson, ",") {
+			if l := len(strings.TrimSpace(v)); l > length {
+				length = l
+			}
+		}
+		return fmt.Sprintf("varchar(%d)", length)
+	case model.ColumnTypeFile:
+		return fmt.Sprintf("varchar(%d)", lengthOr(column.ColumnLength, 500))
+	}
+	if column.StringType == model.StringTypeLongtext {
+		return "text"
+	}
+	return fmt.Sprintf("varchar(%d)", lengthOr(column.ColumnLength, 255))
+}
+
+func (postgresDialect) timeType() string {
+	return "timestamp"
+}
+
+func (postgresDialect) literal(column *model.ColumnConfig, value string) string {
+	if column.ColumnType == model.ColumnTypeBool {
+		if boolValue(value) {
+			return "true"
+		}
+		return "false"
+	}
+	if v, ok := numericLiteral(column, value); ok {
+		return v
+	}
+	return quoteString(value)
+}
+
+func (d postgresDialect) definition(column *columnDef) string {
+	def := d.quote(column.name) + " " + column.sqlType
+	if column.notNull {
+		def += " NOT NULL"
+	}
+	if column.defaultValue != "" {
+		def += " DEFAULT " + column.defaultValue
+	}
+	return def
+}
+
+func (d postgresDialect) columnComment(table string, column *columnDef) string {
+	comment := "NULL"
+	if column.comment != "" {
+		comment = quoteString(column.comment)
+	}
+	return "COMMENT ON COLUMN " + d.quote(table) + "." + d.quote(column.name) + " IS " + comment
+}
+
+func (d postgresDialect) createTable(table string, comment string, columns []*columnDef) []string {
+	lines := make([]string, 0, len(columns)+1)
+	var primaryKeys []string
+	var comments []string
+	for _, column := range columns {
+		lines = append(lines, "  "+d.definition(column))
+		if column.primaryKey {
+			primaryKeys = append(primaryKeys, d.quote(column.name))
+		}
+		if column.comment != "" {
+			comments = append(comments, d.columnComment(table, column))
+		}
+	}
+	if len(primaryKeys) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(primaryKeys, ", ")+")")
+	}
+	statements := []string{"CREATE TABLE " + d.quote(table) + " (\n" + strings.Join(lines, ",\n") + "\n)"}
+	if comment != "" {
+		statements = append(statements, d.tableComment(table, comment))
+	}
+	return append(statements, comments...)
+}
+
+func (d postgresDialect) addColumn(table string, column *columnDef) []string {
+	statements := []string{"ALTER TABLE " + d.quote(table) + " ADD COLUMN " + d.definition(column)}
+	if column.comment != "" {
+		statements = append(statements, d.columnComment(table, column))
+	}
+	return statements
+}
+
+func (d postgresDialect) modifyColumn(table string, old, new *columnDef) []string {
+	var statements []string
+	prefix := "ALTER TABLE " + d.quote(table) + " "
+	if old.name != new.name {
+		statements = append(statements, prefix+"RENAME COLUMN "+d.quote(old.name)+" TO "+d.quote(new.name))
+	}
+	column := d.quote(new.name)
+	if old.sqlType != new.sqlType {
+		statements = append(statements, prefix+"ALTER COLUMN "+column+" TYPE "+new.sqlType+" USING "+column+"::"+new.sqlType)
+	}
+	if old.defaultValue != new.defaultValue {
+		if new.defaultValue == "" {
+			statements = append(statements, prefix+"ALTER COLUMN "+column+" DROP DEFAULT")
+		} else {
+			statements = append(statements, prefix+"ALTER COLUMN "+column+" SET DEFAULT "+new.defaultValue)
+		}
+	}
+	if old.notNull != new.notNull {
+		if new.notNull {
+			statements = append(statements, prefix+"ALTER COLUMN "+column+" SET NOT NULL")
+		} else {
+			statements = append(statements, prefix+"ALTER COLUMN "+column+" DROP NOT NULL")
+		}
+	}
+	if old.comment != new.comment {
+		statements = append(statements, d.columnComment(table, new))
+	}
+	return statements
+}
+
+func (d postgresDialect) renameTable(old, new string) string {
+	return "ALTER TABLE " + d.quote(old) + " RENAME TO " + d.quote(new)
+}
+
+func (d postgresDialect) tableComment(table, comment string) string {
+	if comment == "" {
+		return "COMMENT ON TABLE " + d.quote(table) + " IS NULL"
+	}
+	return "COMMENT ON TABLE " + d.quote(table) + " IS " + quoteString(comment)
+}
+
+// createIndex PostgreSQL不支持前缀索引，设置了前缀长度的字段按整个字段建立索引
+func (d postgresDialect) createIndex(table string, index *indexDef) (string, string) {
+	var warning string
+	columns := make([]string, 0, len(index.columns))
+	for i, column := range index.columns {
+		columns = append(columns, d.quote(column))
+		if index.lengths[i] > 0 {
+			warning = fmt.Sprintf("PostgreSQL不支持前缀索引，索引%s按整个字段建立", index.name)
+		}
+	}
+	statement := "CREATE INDEX "
+	if index.unique {
+		statement = "CREATE UNIQUE INDEX "
+	}
+	return statement + d.quote(index.name) + " ON " + d.quote(table) + " (" + strings.Join(columns, ", ") + ")", warning
+}
+
+func (d postgresDialect) dropIndex(_, index string) string {
+	return "DROP INDEX " + d.quote(index)
+}
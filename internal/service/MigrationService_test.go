@@ -0,0 +1,82 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+func snapshotTable(id, name string, columns ...*model.ColumnConfig) *model.TableSnapshot {
+	return &model.TableSnapshot{
+		TableConfig: &model.TableConfig{Id: id, TableName: name},
+		Columns:     columns,
+	}
+}
+
+func stringColumn(id, name string) *model.ColumnConfig {
+	return &model.ColumnConfig{Id: id, ColumnName: name, ColumnType: model.ColumnTypeString, StringType: model.StringTypeVarchar, ColumnLength: 50}
+}
+
+func TestDiffSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  []*model.TableSnapshot
+		to    []*model.TableSnapshot
+		steps []string
+	}{
+		{
+			name:  "无变化",
+			from:  []*model.TableSnapshot{snapshotTable("t1", "x", stringColumn("c1", "a"))},
+			to:    []*model.TableSnapshot{snapshotTable("t1", "x", stringColumn("c1", "a"))},
+			steps: []string{},
+		},
+		{
+			name:  "重命名表",
+			from:  []*model.TableSnapshot{snapshotTable("t1", "x")},
+			to:    []*model.TableSnapshot{snapshotTable("t1", "y")},
+			steps: []string{"rename-table y"},
+		},
+		{
+			name: "重命名表后新表复用旧表名",
+			from: []*model.TableSnapshot{snapshotTable("t1", "x")},
+			// 新表在前，逐个匹配时会按表名抢占原有的表
+			to:    []*model.TableSnapshot{snapshotTable("t2", "x"), snapshotTable("t1", "y")},
+			steps: []string{"rename-table y", "create-table x"},
+		},
+		{
+			name:  "ID不同时按表名匹配",
+			from:  []*model.TableSnapshot{snapshotTable("t1", "x", stringColumn("c1", "a"))},
+			to:    []*model.TableSnapshot{snapshotTable("t2", "X", stringColumn("c1", "a"))},
+			steps: []string{"rename-table X"},
+		},
+		{
+			name:  "删除表并新建表",
+			from:  []*model.TableSnapshot{snapshotTable("t1", "x")},
+			to:    []*model.TableSnapshot{snapshotTable("t2", "y")},
+			steps: []string{"create-table y", "drop-table x"},
+		},
+		{
+			name:  "重命名字段后新字段复用旧字段名",
+			from:  []*model.TableSnapshot{snapshotTable("t1", "x", stringColumn("c1", "a"))},
+			to:    []*model.TableSnapshot{snapshotTable("t1", "x", stringColumn("c2", "a"), stringColumn("c1", "b"))},
+			steps: []string{"add-column x.a", "modify-column x.b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := diffSchema(mysqlDialect{}, tt.from, tt.to)
+			steps := make([]string, 0)
+			for _, step := range script.Steps {
+				s := step.Action + " " + step.Table
+				if step.Column != "" {
+					s += "." + step.Column
+				}
+				steps = append(steps, s)
+			}
+			if !reflect.DeepEqual(steps, tt.steps) {
+				t.Errorf("steps = %v, want %v\n%s", steps, tt.steps, script.Script)
+			}
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var MockDataService = new(mockDataService)
+
+type mockDataService struct{}
+
+// Generate 为应用的全部表(tableId不为空时只为该表)生成rows行模拟数据。
+// seed为0时随机选取，实际使用的seed在结果中返回，以便重现
+func (s *mockDataService) Generate(applicationId, tableId string, rows int, seed int64) (*model.MockDataSet, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	if rows <= 0 {
+		rows = model.MockDefaultRows
+	}
+	if rows > model.MockMaxRows {
+		return nil, fmt.Errorf("每张表最多生成%d行", model.MockMaxRows)
+	}
+	if exist, err := database.DB.Exist(&model.Application{Id: applicationId}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+	tables, err := TableConfigService.ListWithColumns(applicationId)
+	if err != nil {
+		return nil, err
+	}
+	relations, err := TableRelationService.ListByApplication(applicationId)
+	if err != nil {
+		return nil, err
+	}
+	if tableId != "" {
+		var selected []*model.TableSnapshot
+		for _, table := range tables {
+			if table.Id == tableId {
+				selected = append(selected, table)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, errors.New("表不存在或不属于该应用")
+		}
+		tables = selected
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	g := &mockGenerator{
+		r:    rand.New(rand.NewSource(seed)),
+		ids:  make(map[string][]string),
+		refs: make(map[string]string),
+	}
+	for _, relation := range relations {
+		// 多对一关联的源字段存放目标表的ID
+		if relation.Cardinality == model.RelationManyToOne && relation.SourceColumnId != "" {
+			g.refs[relation.SourceColumnId] = relation.TargetTableId
+		}
+	}
+	set := &model.MockDataSet{Seed: seed, Tables: make([]*model.MockTable, 0, len(tables))}
+	for _, table := range g.order(tables) {
+		mt, err := g.table(table, rows)
+		if err != nil {
+			return nil, err
+		}
+		set.Tables = append(set.Tables, mt)
+	}
+	return set, nil
+}
+
+// Render 将模拟数据导出为指定格式，返回内容及文件扩展名。
+// csv格式每张表一个文件，多张表时打包为zip
+func (s *mockDataService) Render(set *model.MockDataSet, format, dialect string) ([]byte, string, error) {
+	switch format {
+	case model.MockFormatSql, "":
+		if dialect == "" {
+			dialect = model.SqlDialectMysql
+		}
+		d := sqlDialectOf(dialect)
+		if d == nil {
+			return nil, "", fmt.Errorf("不支持的数据库类型%s", dialect)
+		}
+		return renderMockSql(d, set), "sql", nil
+	case model.MockFormatCsv:
+		if len(set.Tables) == 1 {
+			bs, err := renderMockCsv(set.Tables[0])
+			return bs, "csv", err
+		}
+		buf := new(bytes.Buffer)
+		zw := zip.NewWriter(buf)
+		for _, table := range set.Tables {
+			bs, err := renderMockCsv(table)
+			if err != nil {
+				return nil, "", err
+			}
+			w, err := zw.Create(table.TableName + ".csv")
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err = w.Write(bs); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "zip", nil
+	case model.MockFormatJson:
+		bs, err := renderMockJson(set)
+		return bs, "json", err
+	}
+	return nil, "", fmt.Errorf("不支持的导出格式%s", format)
+}
+
+// renderMockSql 每100行合并为一条INSERT语句
+func renderMockSql(d sqlDialect, set *model.MockDataSet) []byte {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- seed: %d\n\n", set.Seed))
+	for _, table := range set.Tables {
+		columns := make([]string, 0, len(table.Columns))
+		for _, column := range table.Columns {
+			columns = append(columns, d.quote(column))
+		}
+		prefix := "INSERT INTO " + d.quote(table.TableName) + " (" + strings.Join(columns, ", ") + ") VALUES\n"
+		for start := 0; start < len(table.Rows); start += 100 {
+			end := start + 100
+			if end > len(table.Rows) {
+				end = len(table.Rows)
+			}
+			sb.WriteString(prefix)
+			for i, row := range table.Rows[start:end] {
+				values := make([]string, 0, len(row))
+				for _, v := range row {
+					values = append(values, mockLiteral(d, v))
+				}
+				sb.WriteString("  (" + strings.Join(values, ", ") + ")")
+				if i < end-start-1 {
+					sb.WriteString(",\n")
+				}
+			}
+			sb.WriteString(";\n")
+		}
+		sb.WriteString("\n")
+	}
+	return []byte(sb.String())
+}
+
+func mockLiteral(d sqlDialect, v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return d.literal(&model.ColumnConfig{ColumnType: model.ColumnTypeBool}, strconv.FormatBool(value))
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case json.Number:
+		return value.String()
+	case json.RawMessage:
+		return quoteString(string(value))
+	}
+	return quoteString(fmt.Sprint(v))
+}
+
+func renderMockCsv(table *model.MockTable) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write(table.Columns); err != nil {
+		return nil, err
+	}
+	for _, row := range table.Rows {
+		record := make([]string, 0, len(row))
+		for _, v := range row {
+			switch value := v.(type) {
+			case nil:
+				record = append(record, "")
+			case json.RawMessage:
+				record = append(record, string(value))
+			default:
+				record = append(record, fmt.Sprint(value))
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// renderMockJson 以 {表名: [{字段名: 值}]} 的形式导出
+func renderMockJson(set *model.MockDataSet) ([]byte, error) {
+	fixtures := make(map[string][]map[string]interface{})
+	for _, table := range set.Tables {
+		records := make([]map[string]interface{}, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			record := make(map[string]interface{}, len(row))
+			for i, v := range row {
+				record[table.Columns[i]] = v
+			}
+			records = append(records, record)
+		}
+		fixtures[table.TableName] = records
+	}
+	return json.MarshalIndent(fixtures, "", "  ")
+}
+
+// mockBaseTime 时间类字段在此之后两年内取值，使用固定时间以保证相同seed生成相同数据
+var mockBaseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const mockTimeRange = 2 * 365 * 24 * 3600
+
+var (
+	mockSurnames = []string{"张", "王", "李", "赵", "刘", "陈", "杨", "黄", "周", "吴", "徐", "孙", "马", "朱", "胡", "林"}
+	mockGivens   = []string{"伟", "芳", "娜", "敏", "静", "磊", "洋", "艳", "勇", "军", "杰", "娟", "涛", "明", "超", "秀英", "晓东", "子涵", "浩然", "雨轩"}
+	mockWords    = []string{"系统", "产品", "服务", "数据", "平台", "方案", "项目", "用户", "市场", "技术", "管理", "信息", "网络", "质量", "计划", "资源", "客户", "流程", "设计", "运营"}
+	mockCities   = []string{"北京市", "上海市", "广州市", "深圳市", "杭州市", "南京市", "成都市", "武汉市", "西安市", "苏州市"}
+	mockRoads    = []string{"人民路", "解放路", "中山路", "建设路", "和平路", "新华路", "长江路", "黄河路"}
+	mockLetters  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	mockFileExts = []string{"jpg", "png", "pdf", "docx", "xlsx"}
+)
+
+type mockGenerator struct {
+	r    *rand.Rand
+	ids  map[string][]string // 表ID -> 已生成的记录ID
+	refs map[string]string   // 关联字段ID -> 目标表ID
+}
+
+// order 被关联的表先生成，以便关联字段取到目标表已生成的ID；存在循环关联时按设计顺序
+func (g *mockGenerator) order(tables []*model.TableSnapshot) []*model.TableSnapshot {
+	included := make(map[string]bool)
+	for _, table := range tables {
+		included[table.Id] = true
+	}
+	done := make(map[string]bool)
+	result := make([]*model.TableSnapshot, 0, len(tables))
+	for len(result) < len(tables) {
+		var next *model.TableSnapshot
+		for _, table := range tables {
+			if done[table.Id] {
+				continue
+			}
+			ready := true
+			for _, column := range table.Columns {
+				if target, ok := g.refs[column.Id]; ok && target != table.Id && included[target] && !done[target] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = table
+				break
+			}
+		}
+		if next == nil {
+			for _, table := range tables {
+				if !done[table.Id] {
+					next = table
+					break
+				}
+			}
+		}
+		done[next.Id] = true
+		result = append(result, next)
+	}
+	return result
+}
+
+func (g *mockGenerator) table(table *model.TableSnapshot, rows int) (*model.MockTable, error) {
+	var columns []*model.ColumnConfig
+	enums := make(map[string][]string)
+	for _, column := range table.Columns {
+		name := strings.ToLower(column.ColumnName)
+		if _, ok := recordTimeColumns[name]; ok || name == "id" {
+			continue
+		}
+		if column.EnumJson != "" {
+			items, err := parseEnumItems(column)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s的枚举值不正确: %s", table.TableName, column.ColumnName, err.Error())
+			}
+			for _, item := range items {
+				enums[column.Id] = append(enums[column.Id], item.Key)
+			}
+		}
+		columns = append(columns, column)
+	}
+	mt := &model.MockTable{TableName: table.TableName, Columns: []string{"id"}, Rows: make([][]interface{}, 0, rows)}
+	for _, column := range columns {
+		mt.Columns = append(mt.Columns, column.ColumnName)
+	}
+	recordTimes := 0
+	for _, rt := range []struct {
+		bit  int
+		name string
+	}{
+		{model.RecordTypeCreateTime, "create_time"},
+		{model.RecordTypeUpdateTime, "update_time"},
+		{model.RecordTypeDeleteTime, "delete_time"},
+	} {
+		if table.RecordType&rt.bit == rt.bit {
+			mt.Columns = append(mt.Columns, rt.name)
+			recordTimes |= rt.bit
+		}
+	}
+
+	// 唯一性校验分组，记录已生成的取值组合
+	groups := make(map[int][]int)
+	for i, column := range columns {
+		if column.UniqueCheck > 0 {
+			groups[column.UniqueCheck] = append(groups[column.UniqueCheck], i)
+		}
+	}
+	// 按分组号顺序处理，保证相同seed生成相同数据
+	groupKeys := make([]int, 0, len(groups))
+	seen := make(map[int]map[string]bool)
+	for k := range groups {
+		groupKeys = append(groupKeys, k)
+		seen[k] = make(map[string]bool)
+	}
+	sort.Ints(groupKeys)
+	idSeen := make(map[string]bool)
+
+	for n := 0; n < rows; n++ {
+		id := strconv.FormatInt(g.r.Int63(), 10)
+		for idSeen[id] {
+			id = strconv.FormatInt(g.r.Int63(), 10)
+		}
+		idSeen[id] = true
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = g.value(table, column, enums[column.Id], column.UniqueCheck > 0)
+		}
+		for _, k := range groupKeys {
+			indexes := groups[k]
+			key := mockKey(values, indexes)
+			for retry := 0; seen[k][key] && retry < 20; retry++ {
+				for _, i := range indexes {
+					values[i] = g.value(table, columns[i], enums[columns[i].Id], true)
+				}
+				key = mockKey(values, indexes)
+			}
+			if seen[k][key] {
+				if !mockMakeUnique(columns, values, indexes, n) {
+					return nil, fmt.Errorf("表%s唯一性校验字段的取值范围不足以生成%d行", table.TableName, rows)
+				}
+				key = mockKey(values, indexes)
+			}
+			seen[k][key] = true
+		}
+
+		row := make([]interface{}, 0, len(mt.Columns))
+		row = append(row, id)
+		row = append(row, values...)
+		createTime := mockBaseTime.Add(time.Duration(g.r.Intn(mockTimeRange)) * time.Second)
+		if recordTimes&model.RecordTypeCreateTime == model.RecordTypeCreateTime {
+			row = append(row, createTime.Format(runtimeDateTimeLayout))
+		}
+		if recordTimes&model.RecordTypeUpdateTime == model.RecordTypeUpdateTime {
+			row = append(row, createTime.Add(time.Duration(g.r.Intn(30*24*3600))*time.Second).Format(runtimeDateTimeLayout))
+		}
+		if recordTimes&model.RecordTypeDeleteTime == model.RecordTypeDeleteTime {
+			row = append(row, nil)
+		}
+		mt.Rows = append(mt.Rows, row)
+		g.ids[table.Id] = append(g.ids[table.Id], id)
+	}
+	return mt, nil
+}
+
+func mockKey(values []interface{}, indexes []int) string {
+	parts := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		parts = append(parts, fmt.Sprint(values[i]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// mockMakeUnique 随机取值无法避免重复时，按行号改写分组中的字符串或整数字段
+func mockMakeUnique(columns []*model.ColumnConfig, values []interface{}, indexes []int, n int) bool {
+	for _, i := range indexes {
+		column := columns[i]
+		if column.EnumJson != "" {
+			continue
+		}
+		switch column.ColumnType {
+		case 0, model.ColumnTypeString:
+			suffix := "-" + strconv.Itoa(n+1)
+			s := fmt.Sprint(values[i])
+			if column.StringType != model.StringTypeLongtext {
+				if limit := lengthOr(column.ColumnLength, 255) - len(suffix); limit < len([]rune(s)) {
+					if limit < 0 {
+						return false
+					}
+					s = string([]rune(s)[:limit])
+				}
+			}
+			values[i] = s + suffix
+			return true
+		case model.ColumnTypeInt, model.ColumnTypeBigInt:
+			// 随机整数不超过1000000
+			values[i] = int64(1000000 + n)
+			return true
+		}
+	}
+	return false
+}
+
+// value 按字段类型、长度、精度及枚举值生成一个值，可为空的字段约十分之一为NULL
+func (g *mockGenerator) value(table *model.TableSnapshot, column *model.ColumnConfig, enumKeys []string, unique bool) interface{} {
+	if column.ZeroValue == "" && !unique && g.r.Intn(10) == 0 {
+		return nil
+	}
+	if target, ok := g.refs[column.Id]; ok {
+		if ids := g.ids[target]; len(ids) > 0 {
+			return ids[g.r.Intn(len(ids))]
+		}
+		if column.ZeroValue == "" && target == table.Id {
+			return nil
+		}
+		return strconv.FormatInt(g.r.Int63(), 10)
+	}
+	if len(enumKeys) > 0 {
+		key := enumKeys[g.r.Intn(len(enumKeys))]
+		switch column.ColumnType {
+		case model.ColumnTypeInt, model.ColumnTypeBigInt:
+			if i, err := strconv.ParseInt(key, 10, 64); err == nil {
+				return i
+			}
+		}
+		return key
+	}
+
+	switch column.ColumnType {
+	case model.ColumnTypeInt:
+		return int64(g.r.Intn(1000))
+	case model.ColumnTypeBigInt:
+		return int64(g.r.Intn(1000000))
+	case model.ColumnTypeDecimal:
+		precision, scale := column.ColumnLength, column.DecimalLength
+		if precision <= 0 {
+			precision, scale = 10, 2
+		}
+		digits := precision - scale
+		if digits > 5 {
+			digits = 5
+		}
+		upper := int64(1)
+		for i := 0; i < digits; i++ {
+			upper *= 10
+		}
+		value := strconv.FormatInt(g.r.Int63n(upper), 10)
+		if scale > 0 {
+			fraction := int64(1)
+			for i := 0; i < scale; i++ {
+				fraction *= 10
+			}
+			value += fmt.Sprintf(".%0*d", scale, g.r.Int63n(fraction))
+		}
+		return json.Number(value)
+	case model.ColumnTypeFloat:
+		return json.Number(strconv.FormatFloat(float64(g.r.Intn(100000))/100, 'f', -1, 64))
+	case model.ColumnTypeBool:
+		return g.r.Intn(2) == 1
+	case model.ColumnTypeDateTime:
+		return mockBaseTime.Add(time.Duration(g.r.Intn(mockTimeRange)) * time.Second).Format(runtimeDateTimeLayout)
+	case model.ColumnTypeDate:
+		return mockBaseTime.AddDate(0, 0, g.r.Intn(2*365)).Format(runtimeDateLayout)
+	case model.ColumnTypeTime:
+		return fmt.Sprintf("%02d:%02d:%02d", g.r.Intn(24), g.r.Intn(60), g.r.Intn(60))
+	case model.ColumnTypeJson:
+		bs, _ := json.Marshal(map[string]interface{}{"label": g.pick(mockWords), "value": g.r.Intn(1000)})
+		return json.RawMessage(bs)
+	case model.ColumnTypeFile:
+		return g.truncate(fmt.Sprintf("https://example.com/files/%x.%s", g.r.Int63(), g.pick(mockFileExts)), lengthOr(column.ColumnLength, 500))
+	}
+
+	s := g.text(strings.ToLower(column.ColumnName), column.StringType == model.StringTypeLongtext)
+	if column.StringType == model.StringTypeLongtext {
+		return s
+	}
+	return g.truncate(s, lengthOr(column.ColumnLength, 255))
+}
+
+// text 按字段名生成较为真实的文本
+func (g *mockGenerator) text(name string, long bool) string {
+	contains := func(keys ...string) bool {
+		for _, key := range keys {
+			if strings.Contains(name, key) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case long:
+		var sb strings.Builder
+		for i := 0; i < 3+g.r.Intn(5); i++ {
+			sb.WriteString(g.sentence())
+		}
+		return sb.String()
+	case contains("email", "mail"):
+		return fmt.Sprintf("user%d@example.com", g.r.Intn(100000))
+	case contains("phone", "mobile", "tel"):
+		return fmt.Sprintf("1%d%09d", 3+g.r.Intn(7), g.r.Intn(1000000000))
+	case contains("url", "link", "avatar", "image", "img", "cover", "icon", "logo"):
+		return fmt.Sprintf("https://example.com/images/%x.png", g.r.Int63())
+	case contains("address", "addr"):
+		return fmt.Sprintf("%s%s%d号", g.pick(mockCities), g.pick(mockRoads), 1+g.r.Intn(300))
+	case contains("ip"):
+		return fmt.Sprintf("192.168.%d.%d", g.r.Intn(256), 1+g.r.Intn(254))
+	case contains("user", "nick", "member", "customer", "author", "contact", "person", "owner"):
+		return g.pick(mockSurnames) + g.pick(mockGivens)
+	case strings.HasSuffix(name, "_id"):
+		return strconv.FormatInt(g.r.Int63(), 10)
+	case strings.HasSuffix(name, "code") || strings.HasSuffix(name, "_no") || strings.HasSuffix(name, "_sn") || name == "no" || name == "sn":
+		return fmt.Sprintf("%c%08d", mockLetters[g.r.Intn(len(mockLetters))], g.r.Intn(100000000))
+	case contains("name", "title", "label", "subject"):
+		return g.pick(mockWords) + g.pick(mockWords) + strconv.Itoa(1+g.r.Intn(999))
+	case contains("desc", "remark", "comment", "summary", "note", "content", "reason"):
+		return g.sentence()
+	}
+	return g.pick(mockWords) + g.pick(mockWords)
+}
+
+func (g *mockGenerator) sentence() string {
+	var sb strings.Builder
+	for i := 0; i < 3+g.r.Intn(4); i++ {
+		sb.WriteString(g.pick(mockWords))
+	}
+	sb.WriteString("。")
+	return sb.String()
+}
+
+func (g *mockGenerator) pick(list []string) string {
+	return list[g.r.Intn(len(list))]
+}
+
+func (g *mockGenerator) truncate(s string, length int) string {
+	if r := []rune(s); len(r) > length {
+		return string(r[:length])
+	}
+	return s
+}
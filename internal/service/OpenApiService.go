@@ -0,0 +1,387 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+	"github.com/yockii/quick-system/internal/util"
+)
+
+var OpenApiService = new(openApiService)
+
+type openApiService struct{}
+
+const openApiSecurityName = "bearerAuth"
+
+// Export 按生成代码的接口约定导出应用的OpenAPI 3文档
+// 每张表生成 POST/PUT/DELETE / 、GET /instance 、GET /list 以及独立更改字段的 PUT /{字段} 接口
+func (s *openApiService) Export(applicationId string) (*model.OpenApiDocument, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	application := new(model.Application)
+	if exist, err := database.DB.ID(applicationId).Get(application); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+	tables, err := TableConfigService.ListWithColumns(applicationId)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &model.OpenApiDocument{
+		Openapi: model.OpenApiVersion,
+		Info: &model.OpenApiInfo{
+			Title:       application.AppName,
+			Description: application.AppDesc,
+			Version:     "1.0.0",
+		},
+		Paths: make(map[string]*model.OpenApiPathItem),
+		Components: &model.OpenApiComponents{
+			Schemas: make(map[string]*model.OpenApiSchema),
+			SecuritySchemes: map[string]*model.OpenApiSecurityScheme{
+				openApiSecurityName: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = application.Package
+	}
+	s.addLogin(doc)
+	for _, table := range tables {
+		if err = s.addTable(doc, table); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// addLogin 生成的应用通过 /login 获取token，其余接口均需携带token
+func (s *openApiService) addLogin(doc *model.OpenApiDocument) {
+	doc.Components.Schemas["LoginRequest"] = &model.OpenApiSchema{
+		Type:     "object",
+		Required: []string{"username", "password"},
+		Properties: map[string]*model.OpenApiSchema{
+			"username": {Type: "string", Title: "用户名"},
+			"password": {Type: "string", Format: "password", Title: "密码"},
+		},
+	}
+	doc.Paths["/login"] = &model.OpenApiPathItem{
+		Post: &model.OpenApiOperation{
+			Tags:        []string{"user"},
+			Summary:     "登录",
+			OperationId: "login",
+			RequestBody: jsonRequestBody(schemaRef("LoginRequest")),
+			Responses:   commonResponses(&model.OpenApiSchema{Type: "string", Description: "token"}),
+		},
+	}
+	doc.Tags = append(doc.Tags, &model.OpenApiTag{Name: "user", Description: "用户"})
+}
+
+func (s *openApiService) addTable(doc *model.OpenApiDocument, table *model.TableSnapshot) error {
+	name := util.PascalCase(table.TableName)
+	path := "/" + util.CamelCase(table.TableName)
+	tagName := table.TableName
+	summaryName := table.TableComment
+	if summaryName == "" {
+		summaryName = table.TableName
+	}
+	doc.Tags = append(doc.Tags, &model.OpenApiTag{Name: tagName, Description: table.TableComment})
+
+	idSchema := &model.OpenApiSchema{Type: "string", Description: "ID"}
+	detail := &model.OpenApiSchema{Type: "object", Description: table.TableComment, Properties: map[string]*model.OpenApiSchema{
+		"id": {Type: "string", Description: "ID", ReadOnly: true},
+	}}
+	listItem := &model.OpenApiSchema{Type: "object", Description: table.TableComment, Properties: map[string]*model.OpenApiSchema{
+		"id": {Type: "string", Description: "ID", ReadOnly: true},
+	}}
+	addRequest := &model.OpenApiSchema{Type: "object", Properties: make(map[string]*model.OpenApiSchema)}
+	updateRequest := &model.OpenApiSchema{Type: "object", Required: []string{"id"}, Properties: map[string]*model.OpenApiSchema{
+		"id": idSchema,
+	}}
+	var searchParameters []*model.OpenApiParameter
+	var updateAloneColumns []*model.ColumnConfig
+	for _, column := range table.Columns {
+		schema, err := s.columnSchema(column)
+		if err != nil {
+			return fmt.Errorf("%s.%s的枚举值不正确: %s", table.TableName, column.ColumnName, err.Error())
+		}
+		field := util.CamelCase(column.ColumnName)
+		displayType := column.DisplayType
+		if displayType == 0 {
+			displayType = model.DisplayTypeAll
+		}
+		if displayType&model.DisplayTypeDetail == model.DisplayTypeDetail {
+			detail.Properties[field] = schema
+		}
+		if displayType&model.DisplayTypeList == model.DisplayTypeList {
+			listItem.Properties[field] = schema
+		}
+		if column.UpdateType&model.UpdateTypeCreate == model.UpdateTypeCreate {
+			addRequest.Properties[field] = schema
+			if column.ZeroValue == model.ZeroValueNotNull {
+				addRequest.Required = append(addRequest.Required, field)
+			}
+		}
+		if column.UpdateAlone == model.UpdateAloneOnly {
+			updateAloneColumns = append(updateAloneColumns, column)
+		} else if column.UpdateType&model.UpdateTypeUpdate == model.UpdateTypeUpdate {
+			updateRequest.Properties[field] = schema
+		}
+		if column.UpdateType&model.UpdateTypeSearch == model.UpdateTypeSearch {
+			searchParameters = append(searchParameters, &model.OpenApiParameter{
+				Name:        field,
+				In:          "query",
+				Description: searchDescription(column),
+				Schema:      schema,
+			})
+		}
+	}
+	// 记录时间由系统维护，只出现在响应中
+	recordTimes := []struct {
+		recordType int
+		field      string
+		title      string
+	}{
+		{model.RecordTypeCreateTime, "createTime", "创建时间"},
+		{model.RecordTypeUpdateTime, "updateTime", "更新时间"},
+	}
+	for _, rt := range recordTimes {
+		if table.RecordType&rt.recordType == rt.recordType {
+			schema := &model.OpenApiSchema{Type: "string", Format: "date-time", Title: rt.title, ReadOnly: true}
+			detail.Properties[rt.field] = schema
+			listItem.Properties[rt.field] = schema
+		}
+	}
+
+	doc.Components.Schemas[name] = detail
+	doc.Components.Schemas[name+"ListItem"] = listItem
+	doc.Components.Schemas[name+"AddRequest"] = addRequest
+	doc.Components.Schemas[name+"UpdateRequest"] = updateRequest
+
+	idParameter := &model.OpenApiParameter{Name: "id", In: "query", Required: true, Schema: idSchema}
+	doc.Paths[path] = &model.OpenApiPathItem{
+		Post: &model.OpenApiOperation{
+			Tags:        []string{tagName},
+			Summary:     "新增" + summaryName,
+			OperationId: "add" + name,
+			RequestBody: jsonRequestBody(schemaRef(name + "AddRequest")),
+			Responses:   commonResponses(schemaRef(name)),
+			Security:    bearerSecurity(),
+		},
+		Put: &model.OpenApiOperation{
+			Tags:        []string{tagName},
+			Summary:     "更改" + summaryName,
+			OperationId: "update" + name,
+			RequestBody: jsonRequestBody(schemaRef(name + "UpdateRequest")),
+			Responses:   commonResponses(&model.OpenApiSchema{Type: "boolean"}),
+			Security:    bearerSecurity(),
+		},
+		Delete: &model.OpenApiOperation{
+			Tags:        []string{tagName},
+			Summary:     "删除" + summaryName,
+			OperationId: "delete" + name,
+			Parameters:  []*model.OpenApiParameter{idParameter},
+			Responses:   commonResponses(&model.OpenApiSchema{Type: "boolean"}),
+			Security:    bearerSecurity(),
+		},
+	}
+	doc.Paths[path+"/instance"] = &model.OpenApiPathItem{
+		Get: &model.OpenApiOperation{
+			Tags:        []string{tagName},
+			Summary:     "获取" + summaryName + "详情",
+			OperationId: "get" + name,
+			Parameters:  []*model.OpenApiParameter{idParameter},
+			Responses:   commonResponses(schemaRef(name)),
+			Security:    bearerSecurity(),
+		},
+	}
+	listParameters := []*model.OpenApiParameter{
+		{Name: "limit", In: "query", Description: "每页数量，-1表示不分页", Schema: &model.OpenApiSchema{Type: "integer", Default: 10}},
+		{Name: "offset", In: "query", Description: "偏移量", Schema: &model.OpenApiSchema{Type: "integer", Default: 0}},
+		{Name: "orderBy", In: "query", Description: "排序，如 xxx-desc,yyy-asc", Schema: &model.OpenApiSchema{Type: "string"}},
+	}
+	doc.Paths[path+"/list"] = &model.OpenApiPathItem{
+		Get: &model.OpenApiOperation{
+			Tags:        []string{tagName},
+			Summary:     "分页查询" + summaryName,
+			OperationId: "list" + name,
+			Parameters:  append(listParameters, searchParameters...),
+			Responses: commonResponses(&model.OpenApiSchema{
+				Type: "object",
+				Properties: map[string]*model.OpenApiSchema{
+					"total":  {Type: "integer"},
+					"offset": {Type: "integer"},
+					"limit":  {Type: "integer"},
+					"items":  {Type: "array", Items: schemaRef(name + "ListItem")},
+				},
+			}),
+			Security: bearerSecurity(),
+		},
+	}
+	for _, column := range updateAloneColumns {
+		field := util.CamelCase(column.ColumnName)
+		schema := detail.Properties[field]
+		if schema == nil {
+			schema, _ = s.columnSchema(column)
+		}
+		columnName := column.DisplayName
+		if columnName == "" {
+			columnName = column.ColumnName
+		}
+		doc.Paths[path+"/"+field] = &model.OpenApiPathItem{
+			Put: &model.OpenApiOperation{
+				Tags:        []string{tagName},
+				Summary:     "更改" + summaryName + columnName,
+				OperationId: "update" + name + util.PascalCase(column.ColumnName),
+				RequestBody: jsonRequestBody(&model.OpenApiSchema{
+					Type:     "object",
+					Required: []string{"id", field},
+					Properties: map[string]*model.OpenApiSchema{
+						"id":  idSchema,
+						field: schema,
+					},
+				}),
+				Responses: commonResponses(&model.OpenApiSchema{Type: "boolean"}),
+				Security:  bearerSecurity(),
+			},
+		}
+	}
+	return nil
+}
+
+// columnSchema 字段对应的schema，ColumnComment作为说明，枚举值作为enum
+func (s *openApiService) columnSchema(column *model.ColumnConfig) (*model.OpenApiSchema, error) {
+	schema := &model.OpenApiSchema{Title: column.DisplayName, Description: column.ColumnComment}
+	switch column.ColumnType {
+	case model.ColumnTypeInt:
+		schema.Type, schema.Format = "integer", "int32"
+	case model.ColumnTypeBigInt:
+		schema.Type, schema.Format = "integer", "int64"
+	case model.ColumnTypeDecimal, model.ColumnTypeFloat:
+		schema.Type, schema.Format = "number", "double"
+	case model.ColumnTypeBool:
+		schema.Type = "boolean"
+	case model.ColumnTypeDateTime:
+		schema.Type, schema.Format = "string", "date-time"
+	case model.ColumnTypeDate:
+		schema.Type, schema.Format = "string", "date"
+	case model.ColumnTypeTime:
+		schema.Type, schema.Format = "string", "time"
+	case model.ColumnTypeJson:
+		// 任意json，不限定类型
+	case model.ColumnTypeFile:
+		schema.Type, schema.Format = "string", "uri"
+	default:
+		schema.Type = "string"
+		if column.StringType != model.StringTypeLongtext && column.ColumnLength > 0 {
+			schema.MaxLength = column.ColumnLength
+		}
+	}
+
+	if column.EnumJson != "" {
+		items, err := parseEnumItems(column)
+		if err != nil {
+			return nil, err
+		}
+		var labels []string
+		for _, item := range items {
+			if schema.Type == "integer" {
+				key, err := strconv.ParseInt(item.Key, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				schema.Enum = append(schema.Enum, key)
+			} else {
+				schema.Enum = append(schema.Enum, item.Key)
+			}
+			if item.Value != item.Key {
+				labels = append(labels, item.Key+"-"+item.Value)
+			}
+		}
+		if len(labels) > 0 {
+			if schema.Description != "" {
+				schema.Description += " "
+			}
+			schema.Description += strings.Join(labels, " ")
+		}
+	}
+
+	if column.ZeroValue != "" && column.ZeroValue != model.ZeroValueNotNull {
+		schema.Default = openApiDefault(schema.Type, column.ZeroValue)
+	}
+	return schema, nil
+}
+
+// openApiDefault 将字段的默认值转换为schema类型对应的值，无法转换时不设置默认值
+func openApiDefault(schemaType string, value string) interface{} {
+	switch schemaType {
+	case "integer":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	case "string":
+		return value
+	}
+	return nil
+}
+
+func searchDescription(column *model.ColumnConfig) string {
+	name := column.DisplayName
+	if name == "" {
+		name = column.ColumnName
+	}
+	switch column.StringSearch {
+	case model.StringSearchPrefix:
+		return name + "，开头模糊匹配"
+	case model.StringSearchFull:
+		return name + "，全量模糊匹配"
+	}
+	return name
+}
+
+func schemaRef(name string) *model.OpenApiSchema {
+	return &model.OpenApiSchema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonRequestBody(schema *model.OpenApiSchema) *model.OpenApiRequestBody {
+	return &model.OpenApiRequestBody{
+		Required: true,
+		Content:  map[string]*model.OpenApiMediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// commonResponses 生成的接口均以 {code, msg, data} 的形式返回，code为0表示成功
+func commonResponses(data *model.OpenApiSchema) map[string]*model.OpenApiResponse {
+	return map[string]*model.OpenApiResponse{
+		"200": {
+			Description: "code为0表示成功，其余为错误码",
+			Content: map[string]*model.OpenApiMediaType{"application/json": {Schema: &model.OpenApiSchema{
+				Type: "object",
+				Properties: map[string]*model.OpenApiSchema{
+					"code": {Type: "integer"},
+					"msg":  {Type: "string"},
+					"data": data,
+				},
+			}}},
+		},
+	}
+}
+
+func bearerSecurity() []map[string][]string {
+	return []map[string][]string{{openApiSecurityName: {}}}
+}
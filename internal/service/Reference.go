@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yockii/qscore/pkg/database"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var (
+	// ErrParentNotFound 引用的应用或表不存在
+	ErrParentNotFound = errors.New("引用的上级记录不存在")
+	// ErrParentMismatch 引用的上级记录之间不一致，如字段的应用与所属表的应用不同
+	ErrParentMismatch = errors.New("引用的上级记录不一致")
+)
+
+// ReferenceError 引用校验错误，可用errors.Is区分是不存在还是不一致
+type ReferenceError struct {
+	Err error
+	Msg string
+}
+
+func (e *ReferenceError) Error() string {
+	return e.Msg
+}
+
+func (e *ReferenceError) Unwrap() error {
+	return e.Err
+}
+
+func parentNotFound(format string, a ...interface{}) error {
+	return &ReferenceError{Err: ErrParentNotFound, Msg: fmt.Sprintf(format, a...)}
+}
+
+func parentMismatch(format string, a ...interface{}) error {
+	return &ReferenceError{Err: ErrParentMismatch, Msg: fmt.Sprintf(format, a...)}
+}
+
+// requireApplication 校验应用存在
+func requireApplication(applicationId string) error {
+	if exist, err := database.DB.Exist(&model.Application{Id: applicationId}); err != nil {
+		return err
+	} else if !exist {
+		return parentNotFound("应用%s不存在", applicationId)
+	}
+	return nil
+}
+
+// requireTable 校验表及其所属应用存在，applicationId不为空时还要求表属于该应用
+func requireTable(tableId, applicationId string) (*model.TableConfig, error) {
+	table := new(model.TableConfig)
+	if has, err := database.DB.ID(tableId).Get(table); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, parentNotFound("表%s不存在", tableId)
+	}
+	if applicationId != "" && table.ApplicationId != applicationId {
+		return nil, parentMismatch("表%s不属于应用%s", table.TableName, applicationId)
+	}
+	if err := requireApplication(table.ApplicationId); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
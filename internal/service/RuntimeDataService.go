@@ -0,0 +1,656 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/util"
+
+	"github.com/yockii/quick-system/internal/model"
+	qsUtil "github.com/yockii/quick-system/internal/util"
+)
+
+var RuntimeDataService = new(runtimeDataService)
+
+// runtimeDataService 运行时模式下的数据增删改查，语义与生成的代码一致:
+// 只接受允许新增/更改的字段，!NIL字段不允许为空，空值取默认值，按UniqueCheck分组校验唯一性，
+// 按StringSearch方式查询，按RecordType维护创建、更新时间并以删除时间做逻辑删除
+type runtimeDataService struct{}
+
+// ErrRuntimeNotFound 路由前缀或表不存在，或应用未运行
+var ErrRuntimeNotFound = errors.New("运行时接口不存在")
+
+// RuntimeDataError 数据校验未通过，错误信息可直接返回给调用方
+type RuntimeDataError struct {
+	Msg string
+}
+
+func (e *RuntimeDataError) Error() string {
+	return e.Msg
+}
+
+func runtimeDataErrorf(format string, args ...interface{}) error {
+	return &RuntimeDataError{Msg: fmt.Sprintf(format, args...)}
+}
+
+const (
+	runtimeDateTimeLayout = "2006-01-02 15:04:05"
+	runtimeDateLayout     = "2006-01-02"
+)
+
+type runtimeColumn struct {
+	*model.ColumnConfig
+	field    string
+	enumKeys map[string]bool
+}
+
+func (c *runtimeColumn) label() string {
+	if c.DisplayName != "" {
+		return c.DisplayName
+	}
+	return c.ColumnName
+}
+
+func (c *runtimeColumn) isString() bool {
+	switch c.ColumnType {
+	case 0, model.ColumnTypeString, model.ColumnTypeEnum, model.ColumnTypeFile:
+		return true
+	}
+	return false
+}
+
+func (c *runtimeColumn) displayable(bit int) bool {
+	displayType := c.DisplayType
+	if displayType == 0 {
+		displayType = model.DisplayTypeAll
+	}
+	return displayType&bit == bit
+}
+
+// convert 将请求中的值转换为写入数据库的值，并校验类型、长度及枚举值
+func (c *runtimeColumn) convert(v interface{}) (interface{}, error) {
+	var result interface{}
+	s, isString := v.(string)
+	if n, ok := v.(json.Number); ok {
+		s, isString = n.String(), true
+	}
+	switch c.ColumnType {
+	case model.ColumnTypeInt, model.ColumnTypeBigInt:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if !isString || err != nil {
+			return nil, runtimeDataErrorf("%s必须为整数", c.label())
+		}
+		result = i
+	case model.ColumnTypeDecimal, model.ColumnTypeFloat:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if !isString || err != nil {
+			return nil, runtimeDataErrorf("%s必须为数字", c.label())
+		}
+		result = f
+	case model.ColumnTypeBool:
+		if b, ok := v.(bool); ok {
+			result = b
+			break
+		}
+		b, err := strconv.ParseBool(s)
+		if !isString || err != nil {
+			return nil, runtimeDataErrorf("%s必须为布尔值", c.label())
+		}
+		result = b
+	case model.ColumnTypeDateTime, model.ColumnTypeDate:
+		if !isString {
+			return nil, runtimeDataErrorf("%s必须为时间字符串", c.label())
+		}
+		t, err := parseRuntimeTime(s)
+		if err != nil {
+			return nil, runtimeDataErrorf("%s的时间格式不正确", c.label())
+		}
+		if c.ColumnType == model.ColumnTypeDate {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		}
+		result = t
+	case model.ColumnTypeTime:
+		if !isString {
+			return nil, runtimeDataErrorf("%s必须为时间字符串", c.label())
+		}
+		t, err := time.Parse("15:04:05", s)
+		if err != nil {
+			if t, err = time.Parse("15:04", s); err != nil {
+				return nil, runtimeDataErrorf("%s的时间格式不正确", c.label())
+			}
+		}
+		result = t.Format("15:04:05")
+	case model.ColumnTypeJson:
+		if isString && json.Valid([]byte(s)) {
+			result = s
+			break
+		}
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return nil, runtimeDataErrorf("%s必须为json", c.label())
+		}
+		result = string(bs)
+	default:
+		if !isString {
+			if b, ok := v.(bool); ok {
+				s = strconv.FormatBool(b)
+			} else {
+				return nil, runtimeDataErrorf("%s必须为字符串", c.label())
+			}
+		}
+		if c.StringType != model.StringTypeLongtext && c.ColumnLength > 0 && utf8.RuneCountInString(s) > c.ColumnLength {
+			return nil, runtimeDataErrorf("%s长度不能超过%d", c.label(), c.ColumnLength)
+		}
+		result = s
+	}
+	if len(c.enumKeys) > 0 && !c.enumKeys[fmt.Sprint(result)] {
+		return nil, runtimeDataErrorf("%s的值%v不在枚举范围内", c.label(), result)
+	}
+	return result, nil
+}
+
+// output 将数据库中读取的值转换为响应中的值
+func (c *runtimeColumn) output(v interface{}) interface{} {
+	if bs, ok := v.([]byte); ok {
+		v = string(bs)
+	}
+	if v == nil {
+		return nil
+	}
+	s, isString := v.(string)
+	switch c.ColumnType {
+	case model.ColumnTypeInt, model.ColumnTypeBigInt:
+		if isString {
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i
+			}
+		}
+	case model.ColumnTypeDecimal, model.ColumnTypeFloat:
+		if isString {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f
+			}
+		}
+	case model.ColumnTypeBool:
+		switch b := v.(type) {
+		case int64:
+			return b != 0
+		case string:
+			return b == "1" || b == "t" || b == "true"
+		}
+	case model.ColumnTypeDateTime:
+		if t, ok := v.(time.Time); ok {
+			return t.Format(runtimeDateTimeLayout)
+		}
+	case model.ColumnTypeDate:
+		if t, ok := v.(time.Time); ok {
+			return t.Format(runtimeDateLayout)
+		}
+	case model.ColumnTypeJson:
+		if isString && json.Valid([]byte(s)) {
+			return json.RawMessage(s)
+		}
+	}
+	return v
+}
+
+func parseRuntimeTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range []string{runtimeDateTimeLayout, time.RFC3339, "2006-01-02T15:04:05", runtimeDateLayout} {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && s == ""
+}
+
+// runtimeTable 运行中的表，name为加上前缀的物理表名
+type runtimeTable struct {
+	d            sqlDialect
+	name         string
+	recordType   int
+	columns      []*runtimeColumn
+	fields       map[string]*runtimeColumn
+	uniqueGroups [][]*runtimeColumn
+}
+
+func newRuntimeTable(tablePrefix string, d sqlDialect, table *model.TableSnapshot) (*runtimeTable, error) {
+	t := &runtimeTable{
+		d:          d,
+		name:       tablePrefix + table.TableName,
+		recordType: table.RecordType,
+		fields:     make(map[string]*runtimeColumn),
+	}
+	groups := make(map[int][]*runtimeColumn)
+	for _, column := range table.Columns {
+		name := strings.ToLower(column.ColumnName)
+		if _, ok := recordTimeColumns[name]; ok || name == "id" {
+			continue
+		}
+		c := &runtimeColumn{ColumnConfig: column, field: qsUtil.CamelCase(column.ColumnName)}
+		if column.EnumJson != "" {
+			items, err := parseEnumItems(column)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s的枚举值不正确: %s", table.TableName, column.ColumnName, err.Error())
+			}
+			c.enumKeys = make(map[string]bool)
+			for _, item := range items {
+				c.enumKeys[item.Key] = true
+			}
+		}
+		t.columns = append(t.columns, c)
+		t.fields[c.field] = c
+		if column.UniqueCheck > 0 {
+			groups[column.UniqueCheck] = append(groups[column.UniqueCheck], c)
+		}
+	}
+	keys := make([]int, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		t.uniqueGroups = append(t.uniqueGroups, groups[k])
+	}
+	return t, nil
+}
+
+func (t *runtimeTable) softDelete() bool {
+	return t.recordType&model.RecordTypeDeleteTime == model.RecordTypeDeleteTime
+}
+
+// where 未被逻辑删除的记录
+func (t *runtimeTable) where() string {
+	if t.softDelete() {
+		return " WHERE " + t.d.quote("delete_time") + " IS NULL"
+	}
+	return " WHERE 1 = 1"
+}
+
+func (t *runtimeTable) query(sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return database.DB.QueryInterface(append([]interface{}{sql}, args...)...)
+}
+
+func (t *runtimeTable) exec(sql string, args ...interface{}) (int64, error) {
+	result, err := database.DB.Exec(append([]interface{}{sql}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (t *runtimeTable) load(id string) (map[string]interface{}, error) {
+	rows, err := t.query("SELECT * FROM "+t.d.quote(t.name)+t.where()+" AND "+t.d.quote("id")+" = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// duplicated 按UniqueCheck分组校验唯一性，values为记录变更后全部字段的值
+func (t *runtimeTable) duplicated(values map[string]interface{}, excludeId string) (bool, error) {
+	for _, group := range t.uniqueGroups {
+		sql := "SELECT " + t.d.quote("id") + " FROM " + t.d.quote(t.name) + t.where()
+		var args []interface{}
+		for _, c := range group {
+			v := values[c.ColumnName]
+			if v == nil {
+				sql += " AND " + t.d.quote(c.ColumnName) + " IS NULL"
+				continue
+			}
+			sql += " AND " + t.d.quote(c.ColumnName) + " = ?"
+			args = append(args, v)
+		}
+		if excludeId != "" {
+			sql += " AND " + t.d.quote("id") + " <> ?"
+			args = append(args, excludeId)
+		}
+		rows, err := t.query(sql+" LIMIT 1", args...)
+		if err != nil {
+			return false, err
+		}
+		if len(rows) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// record 将数据库记录转换为响应，只包含指定显示类型的字段
+func (t *runtimeTable) record(row map[string]interface{}, displayType int) map[string]interface{} {
+	result := map[string]interface{}{"id": runtimeString(row["id"])}
+	for _, c := range t.columns {
+		if c.displayable(displayType) {
+			result[c.field] = c.output(row[c.ColumnName])
+		}
+	}
+	for _, rt := range []struct {
+		bit   int
+		name  string
+		field string
+	}{
+		{model.RecordTypeCreateTime, "create_time", "createTime"},
+		{model.RecordTypeUpdateTime, "update_time", "updateTime"},
+	} {
+		if t.recordType&rt.bit == rt.bit {
+			v := row[rt.name]
+			if tm, ok := v.(time.Time); ok {
+				v = tm.Format(runtimeDateTimeLayout)
+			} else if v != nil {
+				v = runtimeString(v)
+			}
+			result[rt.field] = v
+		}
+	}
+	return result
+}
+
+func runtimeString(v interface{}) string {
+	if bs, ok := v.([]byte); ok {
+		return string(bs)
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func (s *runtimeDataService) table(routePrefix, tableName string) (*runtimeTable, error) {
+	t := ApplicationRuntimeService.table(routePrefix, tableName)
+	if t == nil {
+		return nil, ErrRuntimeNotFound
+	}
+	return t, nil
+}
+
+// Add 新增记录，返回新增后的记录详情
+func (s *runtimeDataService) Add(routePrefix, tableName string, body map[string]interface{}) (isDuplicated bool, record map[string]interface{}, err error) {
+	t, err := s.table(routePrefix, tableName)
+	if err != nil {
+		return false, nil, err
+	}
+	values := make(map[string]interface{})
+	names := []string{"id"}
+	id := util.GenerateDatabaseID()
+	args := []interface{}{id}
+	for _, c := range t.columns {
+		var v interface{}
+		if c.UpdateType&model.UpdateTypeCreate == model.UpdateTypeCreate {
+			v = body[c.field]
+		}
+		if isEmptyValue(v) {
+			if c.ZeroValue == model.ZeroValueNotNull {
+				return false, nil, runtimeDataErrorf("%s不能为空", c.label())
+			}
+			if c.ZeroValue == "" {
+				continue
+			}
+			v = c.ZeroValue
+		}
+		if values[c.ColumnName], err = c.convert(v); err != nil {
+			return false, nil, err
+		}
+		names = append(names, c.ColumnName)
+		args = append(args, values[c.ColumnName])
+	}
+	if isDuplicated, err = t.duplicated(values, ""); err != nil || isDuplicated {
+		return isDuplicated, nil, err
+	}
+	now := time.Now()
+	if t.recordType&model.RecordTypeCreateTime == model.RecordTypeCreateTime {
+		names = append(names, "create_time")
+		args = append(args, now)
+	}
+	if t.recordType&model.RecordTypeUpdateTime == model.RecordTypeUpdateTime {
+		names = append(names, "update_time")
+		args = append(args, now)
+	}
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, t.d.quote(name))
+	}
+	sql := "INSERT INTO " + t.d.quote(t.name) + " (" + strings.Join(quoted, ", ") + ") VALUES (" + strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ") + ")"
+	if _, err = t.exec(sql, args...); err != nil {
+		return false, nil, err
+	}
+	row, err := t.load(id)
+	if err != nil || row == nil {
+		return false, nil, err
+	}
+	return false, t.record(row, model.DisplayTypeDetail), nil
+}
+
+// Update 更改记录中允许更改且非独立更改的字段，请求中未出现的字段保持不变
+func (s *runtimeDataService) Update(routePrefix, tableName string, body map[string]interface{}) (isDuplicated bool, success bool, err error) {
+	t, err := s.table(routePrefix, tableName)
+	if err != nil {
+		return false, false, err
+	}
+	var columns []*runtimeColumn
+	for _, c := range t.columns {
+		if c.UpdateType&model.UpdateTypeUpdate == model.UpdateTypeUpdate && c.UpdateAlone != model.UpdateAloneOnly {
+			if _, ok := body[c.field]; ok {
+				columns = append(columns, c)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return false, false, runtimeDataErrorf("没有可更改的字段")
+	}
+	return s.update(t, body, columns)
+}
+
+// UpdateField 更改独立更改的字段，请求体包含id及该字段
+func (s *runtimeDataService) UpdateField(routePrefix, tableName, field string, body map[string]interface{}) (isDuplicated bool, success bool, err error) {
+	t, err := s.table(routePrefix, tableName)
+	if err != nil {
+		return false, false, err
+	}
+	c, ok := t.fields[field]
+	if !ok || c.UpdateAlone != model.UpdateAloneOnly {
+		return false, false, ErrRuntimeNotFound
+	}
+	return s.update(t, body, []*runtimeColumn{c})
+}
+
+func (s *runtimeDataService) update(t *runtimeTable, body map[string]interface{}, columns []*runtimeColumn) (isDuplicated bool, success bool, err error) {
+	id, _ := body["id"].(string)
+	if id == "" {
+		return false, false, runtimeDataErrorf("ID必须提供")
+	}
+	row, err := t.load(id)
+	if err != nil || row == nil {
+		return false, false, err
+	}
+	values := make(map[string]interface{})
+	for _, c := range t.columns {
+		values[c.ColumnName] = row[c.ColumnName]
+	}
+	var sets []string
+	var args []interface{}
+	for _, c := range columns {
+		v := body[c.field]
+		if isEmptyValue(v) {
+			if c.ZeroValue == model.ZeroValueNotNull {
+				return false, false, runtimeDataErrorf("%s不能为空", c.label())
+			}
+			if c.ZeroValue != "" {
+				v = c.ZeroValue
+			}
+		}
+		if isEmptyValue(v) {
+			values[c.ColumnName] = nil
+		} else if values[c.ColumnName], err = c.convert(v); err != nil {
+			return false, false, err
+		}
+		sets = append(sets, t.d.quote(c.ColumnName)+" = ?")
+		args = append(args, values[c.ColumnName])
+	}
+	if isDuplicated, err = t.duplicated(values, id); err != nil || isDuplicated {
+		return isDuplicated, false, err
+	}
+	if t.recordType&model.RecordTypeUpdateTime == model.RecordTypeUpdateTime {
+		sets = append(sets, t.d.quote("update_time")+" = ?")
+		args = append(args, time.Now())
+	}
+	c, err := t.exec("UPDATE "+t.d.quote(t.name)+" SET "+strings.Join(sets, ", ")+t.where()+" AND "+t.d.quote("id")+" = ?", append(args, id)...)
+	if err != nil {
+		return false, false, err
+	}
+	return false, c > 0, nil
+}
+
+// Remove 删除记录，表记录删除时间时为逻辑删除
+func (s *runtimeDataService) Remove(routePrefix, tableName, id string) (bool, error) {
+	t, err := s.table(routePrefix, tableName)
+	if err != nil {
+		return false, err
+	}
+	if id == "" {
+		return false, runtimeDataErrorf("ID必须提供")
+	}
+	var c int64
+	if t.softDelete() {
+		c, err = t.exec("UPDATE "+t.d.quote(t.name)+" SET "+t.d.quote("delete_time")+" = ?"+t.where()+" AND "+t.d.quote("id")+" = ?", time.Now(), id)
+	} else {
+		c, err = t.exec("DELETE FROM "+t.d.quote(t.name)+" WHERE "+t.d.quote("id")+" = ?", id)
+	}
+	if err != nil {
+		return false, err
+	}
+	return c > 0, nil
+}
+
+// Get 获取记录详情，只包含详情显示的字段
+func (s *runtimeDataService) Get(routePrefix, tableName, id string) (map[string]interface{}, error) {
+	t, err := s.table(routePrefix, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, runtimeDataErrorf("ID必须提供")
+	}
+	row, err := t.load(id)
+	if err != nil || row == nil {
+		return nil, err
+	}
+	return t.record(row, model.DisplayTypeDetail), nil
+}
+
+// Paginate 分页查询，query为请求的查询参数，只有允许作为查询条件的字段生效；
+// orderBy格式为 xxx-desc,yyy-asc ，只能按表中字段排序
+func (s *runtimeDataService) Paginate(routePrefix, tableName string, query func(key string, defaultValue ...string) string, limit, offset int, orderBy string) (int, []map[string]interface{}, error) {
+	t, err := s.table(routePrefix, tableName)
+	if err != nil {
+		return 0, nil, err
+	}
+	where := t.where()
+	var args []interface{}
+	for _, c := range t.columns {
+		if c.UpdateType&model.UpdateTypeSearch != model.UpdateTypeSearch {
+			continue
+		}
+		v := query(c.field)
+		if v == "" {
+			continue
+		}
+		if c.isString() {
+			switch c.StringSearch {
+			case model.StringSearchPrefix:
+				where += " AND " + t.d.quote(c.ColumnName) + " LIKE ?"
+				args = append(args, v+"%")
+				continue
+			case model.StringSearchFull:
+				where += " AND " + t.d.quote(c.ColumnName) + " LIKE ?"
+				args = append(args, "%"+v+"%")
+				continue
+			}
+		}
+		cv, err := c.convert(v)
+		if err != nil {
+			return 0, nil, err
+		}
+		where += " AND " + t.d.quote(c.ColumnName) + " = ?"
+		args = append(args, cv)
+	}
+	order, err := t.orderBy(orderBy)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := t.query("SELECT COUNT(1) AS total FROM "+t.d.quote(t.name)+where, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	total := 0
+	if len(rows) > 0 {
+		total, _ = strconv.Atoi(runtimeString(rows[0]["total"]))
+	}
+	sql := "SELECT * FROM " + t.d.quote(t.name) + where + " ORDER BY " + order
+	if limit > -1 && offset > -1 {
+		sql += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	if rows, err = t.query(sql, args...); err != nil {
+		return 0, nil, err
+	}
+	list := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, t.record(row, model.DisplayTypeList))
+	}
+	return total, list, nil
+}
+
+// orderBy 解析排序参数，未指定时按创建时间倒序(记录创建时间时)或ID排序
+func (t *runtimeTable) orderBy(orderBy string) (string, error) {
+	var obs []string
+	for _, s := range strings.Split(orderBy, ",") {
+		kds := strings.Split(strings.TrimSpace(s), "-")
+		if kds[0] == "" {
+			continue
+		}
+		name := ""
+		if c, ok := t.fields[kds[0]]; ok {
+			name = c.ColumnName
+		} else if kds[0] == "id" {
+			name = "id"
+		} else if kds[0] == "createTime" && t.recordType&model.RecordTypeCreateTime == model.RecordTypeCreateTime {
+			name = "create_time"
+		} else if kds[0] == "updateTime" && t.recordType&model.RecordTypeUpdateTime == model.RecordTypeUpdateTime {
+			name = "update_time"
+		} else {
+			return "", runtimeDataErrorf("不能按%s排序", kds[0])
+		}
+		ob := t.d.quote(name)
+		if len(kds) == 2 && strings.ToLower(kds[1]) == "desc" {
+			ob += " DESC"
+		}
+		obs = append(obs, ob)
+	}
+	if len(obs) == 0 {
+		if t.recordType&model.RecordTypeCreateTime == model.RecordTypeCreateTime {
+			return t.d.quote("create_time") + " DESC", nil
+		}
+		return t.d.quote("id"), nil
+	}
+	return strings.Join(obs, ", "), nil
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/config"
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var SchemaImportService = new(schemaImportService)
+
+type schemaImportService struct{}
+
+// ErrImportSourceNotFound 导入时指定的数据源未在配置文件中登记
+var ErrImportSourceNotFound = errors.New("数据源未配置")
+
+// ImportFromDatabase 读取数据库表结构并转换为应用的表及字段配置
+func (s *schemaImportService) ImportFromDatabase(req *model.SchemaImportRequest) (*model.SchemaImportResult, error) {
+	if req.ApplicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	if exist, err := database.DB.Exist(&model.Application{Id: req.ApplicationId}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, errors.New("ID所指向的应用不存在")
+	}
+
+	engine := database.DB
+	if req.Dsn != "" || req.DataSource != "" {
+		// 直接指定的连接串由调用方确认权限，否则只允许连接配置文件中登记的数据源，避免通过接口探测任意数据库
+		driver, dsn := req.Driver, req.Dsn
+		if dsn == "" {
+			var err error
+			if driver, dsn, err = importDataSource(req.DataSource); err != nil {
+				return nil, err
+			}
+		} else if driver == "" {
+			return nil, errors.New("数据库驱动不能为空")
+		}
+		var err error
+		engine, err = xorm.NewEngine(driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		defer engine.Close()
+	}
+	metas, err := engine.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, t := range req.Tables {
+		wanted[strings.ToLower(t)] = true
+	}
+
+	result := new(model.SchemaImportResult)
+	for _, meta := range metas {
+		if len(wanted) > 0 && !wanted[strings.ToLower(meta.Name)] {
+			continue
+		}
+		table, warnings := tableSnapshotFromMeta(req.ApplicationId, meta)
+		result.Warnings = append(result.Warnings, warnings...)
+		result.Tables = append(result.Tables, table)
+	}
+	if req.Preview {
+		return result, nil
+	}
+	return s.save(req.ApplicationId, result)
+}
+
+// importDataSource 读取配置中登记的导入数据源 importSource.<name>.driver / importSource.<name>.dsn
+func importDataSource(name string) (driver string, dsn string, err error) {
+	if strings.ContainsAny(name, ". ") {
+		return "", "", fmt.Errorf("%w: %s", ErrImportSourceNotFound, name)
+	}
+	driver = config.GetString("importSource." + name + ".driver")
+	dsn = config.GetString("importSource." + name + ".dsn")
+	if driver == "" || dsn == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrImportSourceNotFound, name)
+	}
+	return driver, dsn, nil
+}
+
+// save 在同一事务中写入表及字段配置，应用中已有同名表的跳过，任何一步失败时全部回滚
+func (s *schemaImportService) save(applicationId string, result *model.SchemaImportResult) (*model.SchemaImportResult, error) {
+	saved := make([]*model.TableSnapshot, 0, len(result.Tables))
+	var skipped, warnings []string
+	_, err := database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		if err := requireApplicationIn(session, applicationId); err != nil {
+			return nil, err
+		}
+		for _, table := range result.Tables {
+			imported, messages, err := saveImportedTable(session, applicationId, table)
+			if err != nil {
+				return nil, err
+			}
+			if !imported {
+				skipped = append(skipped, table.TableName)
+				continue
+			}
+			warnings = append(warnings, messages...)
+			saved = append(saved, table)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Tables = saved
+	result.Skipped = append(result.Skipped, skipped...)
+	result.Warnings = append(result.Warnings, warnings...)
+	return result, nil
+}
+
+// requireApplicationIn 在事务中校验应用存在
+func requireApplicationIn(session *xorm.Session, applicationId string) error {
+	if exist, err := session.Exist(&model.Application{Id: applicationId}); err != nil {
+		return err
+	} else if !exist {
+		return parentNotFound("应用%s不存在", applicationId)
+	}
+	return nil
+}
+
+// saveImportedTable 在事务中写入导入的表及其字段，应用中已有同名表时不写入并返回false，重复的字段跳过并返回说明
+func saveImportedTable(session *xorm.Session, applicationId string, table *model.TableSnapshot) (bool, []string, error) {
+	c, err := session.Count(&model.TableConfig{
+		ApplicationId: applicationId,
+		TableName:     table.TableName,
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	if c > 0 {
+		return false, nil, nil
+	}
+	table.Id = model.TableConfigIdPrefix + util.GenerateDatabaseID()
+	table.ApplicationId = applicationId
+	applyTableDefaults(table.TableConfig)
+	// 新增的表排在最后
+	last := new(model.TableConfig)
+	if _, err = session.Where("application_id = ?", applicationId).Desc("sort_order").Cols("sort_order").Get(last); err != nil {
+		return false, nil, err
+	}
+	table.SortOrder = last.SortOrder + 1
+	if _, err = session.Insert(table.TableConfig); err != nil {
+		return false, nil, err
+	}
+
+	var messages []string
+	columns := make([]*model.ColumnConfig, 0, len(table.Columns))
+	names := make(map[string]bool)
+	for _, column := range table.Columns {
+		if names[strings.ToLower(column.ColumnName)] {
+			messages = append(messages, fmt.Sprintf("%s.%s: 字段重复，已跳过", table.TableName, column.ColumnName))
+			continue
+		}
+		names[strings.ToLower(column.ColumnName)] = true
+		column.Id = model.ColumnConfigIdPrefix + util.GenerateDatabaseID()
+		column.ApplicationId = applicationId
+		column.TableId = table.Id
+		column.SortOrder = len(columns) + 1
+		applyColumnDefaults(column)
+		if _, err = session.Insert(column); err != nil {
+			return false, nil, err
+		}
+		columns = append(columns, column)
+	}
+	table.Columns = columns
+	return true, messages, nil
+}
+
+// 由生成器自动维护的字段，导入时不作为普通字段
+var recordTimeColumns = map[string]int{
+	"create_time": model.RecordTypeCreateTime,
+	"update_time": model.RecordTypeUpdateTime,
+	"delete_time": model.RecordTypeDeleteTime,
+}
+
+func tableSnapshotFromMeta(applicationId string, meta *schemas.Table) (*model.TableSnapshot, []string) {
+	var warnings []string
+	table := &model.TableSnapshot{
+		TableConfig: &model.TableConfig{
+			ApplicationId: applicationId,
+			TableName:     meta.Name,
+			TableComment:  meta.Comment,
+		},
+	}
+
+	// 唯一索引转换为唯一性校验分组
+	uniqueGroups := make(map[string]int)
+	group := 0
+	for _, index := range meta.Indexes {
+		if index.Type != schemas.UniqueType {
+			continue
+		}
+		group++
+		for _, col := range index.Cols {
+			if _, ok := uniqueGroups[col]; ok {
+				warnings = append(warnings, fmt.Sprintf("%s.%s: 字段属于多个唯一索引，仅保留第一个", meta.Name, col))
+				continue
+			}
+			uniqueGroups[col] = group
+		}
+	}
+
+	for _, col := range meta.Columns() {
+		name := strings.ToLower(col.Name)
+		if rt, ok := recordTimeColumns[name]; ok {
+			table.RecordType |= rt
+			continue
+		}
+		if col.IsPrimaryKey && name == "id" {
+			continue
+		}
+		column := &model.ColumnConfig{
+			ApplicationId: applicationId,
+			ColumnName:    col.Name,
+			DisplayName:   col.Comment,
+			ColumnComment: col.Comment,
+			UniqueCheck:   uniqueGroups[col.Name],
+		}
+		if column.DisplayName == "" {
+			column.DisplayName = col.Name
+		}
+		length := int(col.Length)
+		if length == 0 {
+			length = int(col.SQLType.DefaultLength)
+		}
+		length2 := int(col.Length2)
+		if length2 == 0 {
+			length2 = int(col.SQLType.DefaultLength2)
+		}
+		if !setColumnType(column, col.SQLType.Name, length, length2) {
+			warnings = append(warnings, fmt.Sprintf("%s.%s: 无法识别的类型%s，已按字符串导入", meta.Name, col.Name, col.SQLType.Name))
+		}
+		if column.ColumnType == model.ColumnTypeEnum {
+			column.EnumJson = strings.Join(enumValuesOf(col.EnumOptions), ",")
+		}
+		var mapped bool
+		column.ZeroValue, mapped = zeroValueOf(col.Nullable, col.Default, isDefaultExpression(col.Default))
+		if !mapped {
+			warnings = append(warnings, fmt.Sprintf("%s.%s: 默认值表达式%s无法映射", meta.Name, col.Name, col.Default))
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	return table, warnings
+}
+
+// setColumnType 按数据库类型设置字段类型相关属性，无法识别时按字符串处理并返回false
+func setColumnType(column *model.ColumnConfig, sqlType string, length, length2 int) bool {
+	fields := strings.Fields(strings.ToUpper(sqlType))
+	if len(fields) == 0 {
+		column.ColumnType = model.ColumnTypeString
+		column.StringType = model.StringTypeVarchar
+		return false
+	}
+	switch fields[0] {
+	case "CHAR", "VARCHAR", "NCHAR", "NVARCHAR", "VARCHAR2", "NVARCHAR2", "CHARACTER", "BPCHAR", "UUID", "SET":
+		column.ColumnType = model.ColumnTypeString
+		column.StringType = model.StringTypeVarchar
+		column.ColumnLength = length
+	case "ENUM":
+		column.ColumnType = model.ColumnTypeEnum
+	case "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "NTEXT", "CLOB", "CITEXT":
+		column.ColumnType = model.ColumnTypeString
+		column.StringType = model.StringTypeLongtext
+	case "JSON", "JSONB":
+		column.ColumnType = model.ColumnTypeJson
+	case "BOOL", "BOOLEAN":
+		column.ColumnType = model.ColumnTypeBool
+	case "TINYINT", "BIT":
+		if length == 1 {
+			column.ColumnType = model.ColumnTypeBool
+		} else {
+			column.ColumnType = model.ColumnTypeInt
+			column.ColumnLength = length
+		}
+	case "BIGINT", "INT8", "BIGSERIAL":
+		column.ColumnType = model.ColumnTypeBigInt
+	case "INT", "INTEGER", "SMALLINT", "MEDIUMINT", "INT2", "INT4", "SERIAL", "SMALLSERIAL", "YEAR":
+		column.ColumnType = model.ColumnTypeInt
+		column.ColumnLength = length
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ":
+		column.ColumnType = model.ColumnTypeDateTime
+	case "DATE":
+		column.ColumnType = model.ColumnTypeDate
+	case "TIME", "TIMETZ":
+		column.ColumnType = model.ColumnTypeTime
+	case "DECIMAL", "NUMERIC", "NUMBER", "MONEY":
+		column.ColumnType = model.ColumnTypeDecimal
+		column.ColumnLength = length
+		column.DecimalLength = length2
+	case "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL":
+		column.ColumnType = model.ColumnTypeFloat
+	default:
+		column.ColumnType = model.ColumnTypeString
+		column.StringType = model.StringTypeVarchar
+		column.ColumnLength = length
+		return false
+	}
+	return true
+}
+
+// enumValuesOf 按定义顺序返回枚举值
+func enumValuesOf(options map[string]int) []string {
+	values := make([]string, 0, len(options))
+	for v := range options {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return options[values[i]] < options[values[j]]
+	})
+	return values
+}
+
+// zeroValueOf 数据库表及建表脚本导入共用的空值映射：有默认值的以默认值作为空值，非空且无默认值的不允许为空。
+// 默认值为表达式时无法映射，非空字段按不允许为空处理，mapped返回false
+func zeroValueOf(nullable bool, defaultValue string, isExpr bool) (zeroValue string, mapped bool) {
+	if isExpr {
+		if !nullable {
+			return model.ZeroValueNotNull, false
+		}
+		return "", false
+	}
+	defaultValue = strings.Trim(trimDefaultCast(defaultValue), "'\"")
+	if strings.EqualFold(defaultValue, "NULL") {
+		defaultValue = ""
+	}
+	if defaultValue != "" {
+		return defaultValue, true
+	}
+	if !nullable {
+		return model.ZeroValueNotNull, true
+	}
+	return "", true
+}
+
+// isDefaultExpression 判断从数据库读取的默认值是否为函数或表达式，如CURRENT_TIMESTAMP、nextval('seq'::regclass)
+func isDefaultExpression(defaultValue string) bool {
+	v := trimDefaultCast(defaultValue)
+	quoted := len(v) >= 2 && (v[0] == '\'' || v[0] == '"') && v[len(v)-1] == v[0]
+	v = strings.ToUpper(strings.Trim(v, "'\""))
+	switch v {
+	case "CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "LOCALTIME", "LOCALTIMESTAMP", "NOW()":
+		return true
+	}
+	return !quoted && strings.Contains(v, "(")
+}
+
+// trimDefaultCast 去掉字面量默认值上PostgreSQL的类型转换，如 'a'::character varying
+func trimDefaultCast(defaultValue string) string {
+	v := strings.TrimSpace(defaultValue)
+	if i := strings.LastIndex(v, "::"); i > 0 && strings.HasSuffix(v[:i], "'") {
+		return v[:i]
+	}
+	return v
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/yockii/quick-system/internal/model"
+	qsUtil "github.com/yockii/quick-system/internal/util"
+)
+
+var SourceCheckService = new(sourceCheckService)
+
+type sourceCheckService struct{}
+
+// errSourceImport 外部依赖无法在源码包内解析，类型检查时忽略
+var errSourceImport = errors.New("未能解析的外部依赖")
+
+// Check 在内存中解压生成的源码包，对每个go文件做语法解析及gofmt检查，并对各个包做类型检查。
+// 标准库按本机的Go环境解析，由无法解析的依赖连带产生的类型错误只作为警告
+func (s *sourceCheckService) Check(source []byte) (*model.SourceCheckResult, error) {
+	files, err := qsUtil.ReadZipFiles(source)
+	if err != nil {
+		return nil, err
+	}
+	c := &sourceChecker{
+		fset:     token.NewFileSet(),
+		files:    files,
+		result:   &model.SourceCheckResult{Issues: make([]*model.SourceCheckIssue, 0)},
+		parsed:   make(map[string]*ast.File),
+		broken:   make(map[string]bool),
+		dirs:     make(map[string][]string),
+		paths:    make(map[string]string),
+		packages: make(map[string]*types.Package),
+		checking: make(map[string]bool),
+		stdFails: make(map[string]bool),
+		std:      importer.Default(),
+	}
+	c.ctx = build.Default
+	c.ctx.OpenFile = func(p string) (io.ReadCloser, error) {
+		content, ok := files[p]
+		if !ok {
+			return nil, fmt.Errorf("文件%s不存在", p)
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	c.ctx.JoinPath = path.Join
+
+	var names []string
+	for name := range files {
+		if strings.HasSuffix(name, ".go") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	c.result.Files = len(names)
+	for _, name := range names {
+		c.parseFile(name)
+	}
+	c.resolveImportPaths()
+
+	var dirs []string
+	for dir := range c.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		_, _ = c.checkPackage(dir)
+	}
+	return c.result, nil
+}
+
+type sourceChecker struct {
+	fset     *token.FileSet
+	ctx      build.Context
+	files    map[string][]byte
+	result   *model.SourceCheckResult
+	parsed   map[string]*ast.File      // 解析成功的文件
+	broken   map[string]bool           // 存在语法错误的目录
+	dirs     map[string][]string       // 目录 -> 参与类型检查的文件
+	paths    map[string]string         // 导入路径 -> 目录
+	dirPaths map[string]string         // 目录 -> 导入路径
+	packages map[string]*types.Package // 目录 -> 已检查的包
+	checking map[string]bool
+	stdFails map[string]bool // 无法解析的标准库包
+	std      types.Importer
+}
+
+func (c *sourceChecker) addIssue(level, stage, file string, line, column int, msg string) {
+	if level == model.LintLevelError {
+		c.result.Errors++
+	} else {
+		c.result.Warnings++
+	}
+	if len(c.result.Issues) >= model.SourceCheckMaxIssues {
+		c.result.Truncated = true
+		return
+	}
+	c.result.Issues = append(c.result.Issues, &model.SourceCheckIssue{
+		Level:   level,
+		Stage:   stage,
+		Path:    file,
+		Line:    line,
+		Column:  column,
+		Message: msg,
+	})
+}
+
+func (c *sourceChecker) parseFile(name string) {
+	content := c.files[name]
+	dir := path.Dir(name)
+	f, err := parser.ParseFile(c.fset, name, content, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		var list scanner.ErrorList
+		if errors.As(err, &list) {
+			for _, e := range list {
+				c.addIssue(model.LintLevelError, model.SourceCheckStageParse, name, e.Pos.Line, e.Pos.Column, e.Msg)
+			}
+		} else {
+			c.addIssue(model.LintLevelError, model.SourceCheckStageParse, name, 0, 0, err.Error())
+		}
+		c.broken[dir] = true
+		return
+	}
+	c.parsed[name] = f
+
+	if formatted, err := format.Source(content); err != nil {
+		c.addIssue(model.LintLevelError, model.SourceCheckStageFormat, name, 0, 0, err.Error())
+	} else if !bytes.Equal(formatted, content) {
+		c.addIssue(model.LintLevelWarning, model.SourceCheckStageFormat, name, firstDiffLine(content, formatted), 0, "文件未按gofmt格式化")
+	}
+
+	// 测试文件、testdata目录及构建约束不满足的文件不参与类型检查
+	if strings.HasSuffix(name, "_test.go") || isIgnoredSourceDir(dir) {
+		return
+	}
+	if match, err := c.ctx.MatchFile(dir, path.Base(name)); err != nil || !match {
+		return
+	}
+	c.dirs[dir] = append(c.dirs[dir], name)
+}
+
+// resolveImportPaths 按源码包内的go.mod计算各目录的导入路径
+func (c *sourceChecker) resolveImportPaths() {
+	modules := make(map[string]string) // 模块根目录 -> 模块路径
+	for name, content := range c.files {
+		if path.Base(name) != "go.mod" {
+			continue
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "module ") {
+				modules[path.Dir(name)] = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+				break
+			}
+		}
+	}
+	c.dirPaths = make(map[string]string)
+	for dir := range c.dirs {
+		root, importPath := "", ""
+		for r, module := range modules {
+			if (r == "." || dir == r || strings.HasPrefix(dir, r+"/")) && len(r) >= len(root) {
+				root, importPath = r, module
+			}
+		}
+		if importPath == "" {
+			// 没有go.mod时以目录作为导入路径
+			importPath = dir
+		} else if dir != root {
+			rel := dir
+			if root != "." {
+				rel = strings.TrimPrefix(dir, root+"/")
+			}
+			importPath += "/" + rel
+		}
+		c.paths[importPath] = dir
+		c.dirPaths[dir] = importPath
+	}
+}
+
+// Import 实现types.Importer，优先解析源码包内的包
+func (c *sourceChecker) Import(importPath string) (*types.Package, error) {
+	if dir, ok := c.paths[importPath]; ok {
+		return c.checkPackage(dir)
+	}
+	if first := strings.Split(importPath, "/")[0]; !strings.Contains(first, ".") && importPath != "C" {
+		pkg, err := c.std.Import(importPath)
+		if err == nil {
+			return pkg, nil
+		}
+		// 标准库需要本机的Go工具链才能解析，缺失时需提示类型检查并不完整
+		if !c.stdFails[importPath] {
+			c.stdFails[importPath] = true
+			c.addIssue(model.LintLevelWarning, model.SourceCheckStageType, "", 0, 0,
+				fmt.Sprintf("无法解析标准库%s，可能缺少Go工具链，类型检查不完整: %v", importPath, err))
+		}
+	}
+	return nil, errSourceImport
+}
+
+func (c *sourceChecker) checkPackage(dir string) (*types.Package, error) {
+	if pkg, ok := c.packages[dir]; ok {
+		if pkg == nil {
+			return nil, errSourceImport
+		}
+		return pkg, nil
+	}
+	if c.checking[dir] {
+		// 导入失败的提示会被忽略，循环导入需要单独记录
+		c.addIssue(model.LintLevelError, model.SourceCheckStageType, dir, 0, 0, "存在循环导入: "+c.dirPaths[dir])
+		return nil, errSourceImport
+	}
+	// 语法错误的包无法可靠地做类型检查
+	if c.broken[dir] {
+		c.packages[dir] = nil
+		return nil, errSourceImport
+	}
+	c.checking[dir] = true
+	defer delete(c.checking, dir)
+
+	var files []*ast.File
+	for _, name := range c.dirs[dir] {
+		f := c.parsed[name]
+		// 外部依赖导入失败时types按路径最后一段命名，如 fiber/v2 会被命名为v2，这里按惯例推断包名
+		for _, spec := range f.Imports {
+			importPath := strings.Trim(spec.Path.Value, `"`)
+			if _, ok := c.paths[importPath]; ok || spec.Name != nil || !strings.Contains(strings.Split(importPath, "/")[0], ".") {
+				continue
+			}
+			spec.Name = ast.NewIdent(guessPackageName(importPath))
+		}
+		files = append(files, f)
+	}
+	// 依赖无法解析时其后的部分错误是连带产生的（如嵌入的结构体字段未定义），先收集，检查完成后再决定级别
+	var typeErrors []types.Error
+	unresolved := make(map[string]bool) // 无法解析的导入路径
+	conf := types.Config{
+		Importer: c,
+		Error: func(err error) {
+			var te types.Error
+			if !errors.As(err, &te) {
+				c.addIssue(model.LintLevelError, model.SourceCheckStageType, dir, 0, 0, err.Error())
+				return
+			}
+			// 无法解析的依赖不算作生成代码的错误
+			if strings.HasPrefix(te.Msg, "could not import ") {
+				unresolved[strings.SplitN(strings.TrimPrefix(te.Msg, "could not import "), " ", 2)[0]] = true
+				return
+			}
+			typeErrors = append(typeErrors, te)
+		},
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	pkg, _ := conf.Check(c.dirPaths[dir], c.fset, files, info)
+	derived := derivedErrorChecker(pkg, files, info, unresolved)
+	noted := false
+	for _, te := range typeErrors {
+		level := model.LintLevelError
+		if derived(te) {
+			level = model.LintLevelWarning
+			if !noted {
+				noted = true
+				c.addIssue(level, model.SourceCheckStageType, dir, 0, 0, "部分类型错误由无法解析的依赖导致，仅作为警告")
+			}
+		}
+		pos := te.Fset.Position(te.Pos)
+		c.addIssue(level, model.SourceCheckStageType, pos.Filename, pos.Line, pos.Column, te.Msg)
+	}
+	c.packages[dir] = pkg
+	c.result.Packages++
+	if pkg == nil {
+		return nil, errSourceImport
+	}
+	return pkg, nil
+}
+
+// derivedErrorChecker 返回判断类型错误是否由无法解析的依赖导致的函数：
+// 错误位于类型不完整（嵌入了无法解析的类型）的值的字段选择或结构体字面量上，
+// 或错误信息涉及无效类型、不完整的类型及无法解析的包时视为连带错误，其余错误仍是生成代码本身的问题
+func derivedErrorChecker(pkg *types.Package, files []*ast.File, info *types.Info, unresolved map[string]bool) func(types.Error) bool {
+	seen := make(map[types.Type]bool)
+	positions := make(map[token.Pos]bool)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if tv, ok := info.Types[n.X]; ok && isIncompleteType(tv.Type, seen) {
+					positions[n.Pos()] = true
+					positions[n.Sel.Pos()] = true
+				}
+			case *ast.CompositeLit:
+				if tv, ok := info.Types[n]; ok && isIncompleteType(tv.Type, seen) {
+					for _, elt := range n.Elts {
+						if kv, ok := elt.(*ast.KeyValueExpr); ok {
+							positions[kv.Key.Pos()] = true
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	// 错误信息中可能出现的不完整类型名及无法解析的包名
+	var names []string
+	if pkg != nil {
+		scopes := []*types.Scope{pkg.Scope()}
+		for _, imported := range pkg.Imports() {
+			scopes = append(scopes, imported.Scope())
+		}
+		for _, scope := range scopes {
+			for _, name := range scope.Names() {
+				if tn, ok := scope.Lookup(name).(*types.TypeName); ok && isIncompleteType(tn.Type(), seen) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	var qualifiers []string
+	for _, f := range files {
+		for _, spec := range f.Imports {
+			if importPath := strings.Trim(spec.Path.Value, `"`); unresolved[importPath] {
+				name := guessPackageName(importPath)
+				if spec.Name != nil {
+					name = spec.Name.Name
+				}
+				qualifiers = append(qualifiers, name+".")
+			}
+		}
+	}
+
+	return func(te types.Error) bool {
+		if positions[te.Pos] || strings.Contains(te.Msg, "invalid type") {
+			return true
+		}
+		for _, name := range names {
+			if containsWord(te.Msg, name) {
+				return true
+			}
+		}
+		for _, qualifier := range qualifiers {
+			if containsWord(te.Msg, qualifier) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isIncompleteType 类型本身无效，或结构体、接口嵌入了无效的类型，其字段及方法集不完整
+func isIncompleteType(t types.Type, seen map[types.Type]bool) bool {
+	if t == nil {
+		return false
+	}
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if incomplete, ok := seen[t]; ok {
+		return incomplete
+	}
+	// 递归的类型先按完整处理
+	seen[t] = false
+	incomplete := false
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		incomplete = u.Kind() == types.Invalid
+	case *types.Struct:
+		for i := 0; i < u.NumFields() && !incomplete; i++ {
+			if field := u.Field(i); field.Embedded() {
+				incomplete = isIncompleteType(field.Type(), seen)
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumEmbeddeds() && !incomplete; i++ {
+			incomplete = isIncompleteType(u.EmbeddedType(i), seen)
+		}
+	}
+	seen[t] = incomplete
+	return incomplete
+}
+
+// containsWord 判断s中包含word且其前面不是标识符字符
+func containsWord(s, word string) bool {
+	for i := strings.Index(s, word); i >= 0; {
+		end := i + len(word)
+		if (i == 0 || !isIdentByte(s[i-1])) && (end == len(s) || !isIdentByte(s[end]) || strings.HasSuffix(word, ".")) {
+			return true
+		}
+		next := strings.Index(s[i+1:], word)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// guessPackageName 按导入路径推断包名，忽略版本后缀及go-前缀
+func guessPackageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "-go")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func isIgnoredSourceDir(dir string) bool {
+	for _, part := range strings.Split(dir, "/") {
+		if part == "testdata" || part == "vendor" || strings.HasPrefix(part, "_") || (strings.HasPrefix(part, ".") && part != ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// firstDiffLine 两段内容第一处不同所在的行号
+func firstDiffLine(a, b []byte) int {
+	line := 1
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return line
+		}
+		if a[i] == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+// sourceCheckMessage 校验未通过时的失败原因，列出前几处错误
+func sourceCheckMessage(result *model.SourceCheckResult) string {
+	var lines []string
+	for _, issue := range result.Issues {
+		if issue.Level != model.LintLevelError {
+			continue
+		}
+		if len(lines) == 5 {
+			lines = append(lines, "...")
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", issue.Path, issue.Line, issue.Column, issue.Message))
+	}
+	return fmt.Sprintf("生成的代码校验未通过，共%d处错误\n%s", result.Errors, strings.Join(lines, "\n"))
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/yockii/qscore/pkg/database"
 	"github.com/yockii/qscore/pkg/domain"
 	"github.com/yockii/qscore/pkg/util"
+	"xorm.io/xorm"
 
 	"github.com/yockii/quick-system/internal/model"
 )
@@ -21,6 +23,9 @@ func (s *tableConfigService) Add(instance *model.TableConfig) (isDuplicated bool
 	if instance.TableName == "" {
 		return false, false, errors.New("表名不能为空")
 	}
+	if err = requireApplication(instance.ApplicationId); err != nil {
+		return
+	}
 	var c int64 = 0
 	c, err = database.DB.Count(&model.TableConfig{
 		ApplicationId: instance.ApplicationId,
@@ -35,26 +40,46 @@ func (s *tableConfigService) Add(instance *model.TableConfig) (isDuplicated bool
 	}
 
 	instance.Id = model.TableConfigIdPrefix + util.GenerateDatabaseID()
-	if instance.RecordType == 0 {
-		instance.RecordType = 1
+	applyTableDefaults(instance)
+	if instance.SortOrder == 0 {
+		// 新增的表排在最后
+		last := new(model.TableConfig)
+		if _, err = database.DB.Where("application_id = ?", instance.ApplicationId).Desc("sort_order").Cols("sort_order").Get(last); err != nil {
+			return
+		}
+		instance.SortOrder = last.SortOrder + 1
 	}
 	_, err = database.DB.Insert(instance)
 	success = err == nil
 	return
 }
 
-func (s *tableConfigService) Remove(instance *model.TableConfig) (bool, error) {
+// applyTableDefaults 为未设置的表属性填充默认值
+func applyTableDefaults(instance *model.TableConfig) {
+	if instance.RecordType == 0 {
+		instance.RecordType = 1
+	}
+}
+
+// Remove 删除表及其字段、索引和关联到该表的表关联，dryRun为true时仅统计将删除的记录数。表不存在时返回nil
+func (s *tableConfigService) Remove(instance *model.TableConfig, dryRun bool) (*model.CascadeDeleteResult, error) {
 	if instance.Id == "" {
-		return false, errors.New("id不能为空")
+		return nil, errors.New("id不能为空")
 	}
-	c, err := database.DB.Delete(instance)
-	if err != nil {
-		return false, err
+	if exist, err := database.DB.Exist(&model.TableConfig{Id: instance.Id}); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, nil
 	}
-	if c == 0 {
-		return false, nil
+	plan := new(cascadePlan)
+	plan.addWhere("table_relation", &model.TableRelation{}, "source_table_id = ? OR target_table_id = ?", instance.Id, instance.Id)
+	plan.addWhere("table_index", &model.TableIndex{}, "table_id = ?", instance.Id)
+	plan.addWhere("column_config", &model.ColumnConfig{}, "table_id = ?", instance.Id)
+	plan.addWhere("table_config", &model.TableConfig{}, "id = ?", instance.Id)
+	if dryRun {
+		return plan.preview()
 	}
-	return true, nil
+	return plan.execute()
 }
 
 func (s *tableConfigService) Update(instance *model.TableConfig) (bool, error) {
@@ -62,12 +87,25 @@ func (s *tableConfigService) Update(instance *model.TableConfig) (bool, error) {
 		return false, errors.New("ID不能为空")
 	}
 	// 不允许更改的字段
+	// 表不能移动到其他应用，否则其字段、索引及关联将分属不同应用
+	if instance.ApplicationId != "" {
+		old := new(model.TableConfig)
+		if has, err := database.DB.ID(instance.Id).Cols("application_id").Get(old); err != nil {
+			return false, err
+		} else if !has {
+			return false, nil
+		}
+		if old.ApplicationId != instance.ApplicationId {
+			return false, parentMismatch("表不能移动到其他应用")
+		}
+	}
 
 	c, err := database.DB.ID(instance.Id).Update(&model.TableConfig{
 		// 允许更改的字段
 		TableName:    instance.TableName,
 		TableComment: instance.TableComment,
 		RecordType:   instance.RecordType,
+		SortOrder:    instance.SortOrder,
 	})
 	if err != nil {
 		return false, err
@@ -108,7 +146,7 @@ func (s *tableConfigService) PaginateBetweenTimes(condition *model.TableConfig,
 	if orderBy != "" {
 		session.OrderBy(orderBy)
 	}
-	session.Desc("create_time")
+	session.Asc("sort_order", "id")
 
 	// 处理时间字段，在某段时间之间
 	for tc, tr := range tcList {
@@ -139,3 +177,72 @@ func (s *tableConfigService) PaginateBetweenTimes(condition *model.TableConfig,
 	}
 	return int(total), list, nil
 }
+
+// ListWithColumns 列出应用的所有表及其字段、索引配置
+func (s *tableConfigService) ListWithColumns(applicationId string) ([]*model.TableSnapshot, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	var tables []*model.TableConfig
+	if err := database.DB.Asc("sort_order", "id").Find(&tables, &model.TableConfig{ApplicationId: applicationId}); err != nil {
+		return nil, err
+	}
+	result := make([]*model.TableSnapshot, 0, len(tables))
+	for _, table := range tables {
+		var columns []*model.ColumnConfig
+		if err := database.DB.Asc("sort_order", "id").Find(&columns, &model.ColumnConfig{TableId: table.Id}); err != nil {
+			return nil, err
+		}
+		var indexes []*model.TableIndex
+		if err := database.DB.Find(&indexes, &model.TableIndex{TableId: table.Id}); err != nil {
+			return nil, err
+		}
+		result = append(result, &model.TableSnapshot{TableConfig: table, Columns: columns, Indexes: indexes})
+	}
+	return result, nil
+}
+
+// Reorder 按ids的顺序重新设置应用内所有表的排序，ids必须包含应用的全部表
+func (s *tableConfigService) Reorder(applicationId string, ids []string) error {
+	if applicationId == "" {
+		return errors.New("应用ID不能为空")
+	}
+	var tables []*model.TableConfig
+	if err := database.DB.Cols("id").Find(&tables, &model.TableConfig{ApplicationId: applicationId}); err != nil {
+		return err
+	}
+	existing := make([]string, 0, len(tables))
+	for _, table := range tables {
+		existing = append(existing, table.Id)
+	}
+	if err := checkReorderIds(existing, ids); err != nil {
+		return err
+	}
+	_, err := database.DB.Transaction(func(session *xorm.Session) (interface{}, error) {
+		for i, id := range ids {
+			if _, err := session.ID(id).Cols("sort_order").Update(&model.TableConfig{SortOrder: i + 1}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// checkReorderIds 校验排序的ID列表与现有记录完全一致，不允许缺少、重复或多出
+func checkReorderIds(existing []string, ids []string) error {
+	if len(ids) != len(existing) {
+		return fmt.Errorf("排序列表必须包含全部%d条记录", len(existing))
+	}
+	remaining := make(map[string]bool)
+	for _, id := range existing {
+		remaining[id] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return fmt.Errorf("ID %s 不存在或重复", id)
+		}
+		delete(remaining, id)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/util"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var TableIndexService = new(tableIndexService)
+
+type tableIndexService struct{}
+
+func (s *tableIndexService) Add(instance *model.TableIndex) (isDuplicated bool, success bool, err error) {
+	if instance.TableId == "" {
+		return false, false, errors.New("表ID不能为空")
+	}
+	var columns []*model.ColumnConfig
+	if columns, err = s.validate(instance); err != nil {
+		return
+	}
+	if instance.IndexName == "" {
+		instance.IndexName = s.defaultName(instance, columns)
+	}
+	var c int64 = 0
+	c, err = database.DB.Count(&model.TableIndex{
+		TableId:   instance.TableId,
+		IndexName: instance.IndexName,
+	})
+	if err != nil {
+		return
+	}
+	if c > 0 {
+		isDuplicated = true
+		return
+	}
+
+	instance.Id = model.TableIndexIdPrefix + util.GenerateDatabaseID()
+	_, err = database.DB.Insert(instance)
+	success = err == nil
+	return
+}
+
+// validate 校验索引所属的表及索引字段，返回索引字段对应的字段配置
+func (s *tableIndexService) validate(instance *model.TableIndex) ([]*model.ColumnConfig, error) {
+	table := new(model.TableConfig)
+	if has, err := database.DB.ID(instance.TableId).Get(table); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, parentNotFound("索引所属的表不存在")
+	}
+	if instance.ApplicationId != "" && instance.ApplicationId != table.ApplicationId {
+		return nil, parentMismatch("索引所属的表不属于该应用")
+	}
+	instance.ApplicationId = table.ApplicationId
+
+	if instance.IndexName != "" {
+		if !identifierPattern.MatchString(instance.IndexName) {
+			return nil, errors.New("索引名只能包含字母、数字及下划线，且不能以数字开头")
+		}
+		if len(instance.IndexName) > maxNameLength {
+			return nil, fmt.Errorf("索引名超过%d个字符", maxNameLength)
+		}
+	}
+	if instance.IsUnique != 0 && instance.IsUnique != 1 {
+		return nil, errors.New("是否唯一索引的值不正确")
+	}
+	if len(instance.Columns) == 0 {
+		return nil, errors.New("索引字段不能为空")
+	}
+
+	var tableColumns []*model.ColumnConfig
+	if err := database.DB.Find(&tableColumns, &model.ColumnConfig{TableId: table.Id}); err != nil {
+		return nil, err
+	}
+	columnMap := make(map[string]*model.ColumnConfig)
+	for _, column := range tableColumns {
+		columnMap[column.Id] = column
+	}
+	used := make(map[string]bool)
+	columns := make([]*model.ColumnConfig, 0, len(instance.Columns))
+	for _, ic := range instance.Columns {
+		if ic == nil || ic.ColumnId == "" {
+			return nil, errors.New("索引字段ID不能为空")
+		}
+		column, ok := columnMap[ic.ColumnId]
+		if !ok {
+			return nil, parentMismatch("索引字段不属于该表")
+		}
+		if used[ic.ColumnId] {
+			return nil, fmt.Errorf("索引字段%s重复", column.ColumnName)
+		}
+		used[ic.ColumnId] = true
+		if ic.PrefixLength < 0 {
+			return nil, errors.New("前缀长度不能为负数")
+		}
+		if ic.PrefixLength > 0 {
+			if column.ColumnType != model.ColumnTypeString && column.ColumnType != 0 {
+				return nil, fmt.Errorf("字段%s不是字符串，不能设置前缀长度", column.ColumnName)
+			}
+			if column.StringType != model.StringTypeLongtext && column.ColumnLength > 0 && ic.PrefixLength > column.ColumnLength {
+				return nil, fmt.Errorf("字段%s的前缀长度不能超过字段长度%d", column.ColumnName, column.ColumnLength)
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// defaultName 未指定索引名时按 idx_表名_字段名 / uk_表名_字段名 生成
+func (s *tableIndexService) defaultName(instance *model.TableIndex, columns []*model.ColumnConfig) string {
+	table := new(model.TableConfig)
+	_, _ = database.DB.ID(instance.TableId).Cols("table_name").Get(table)
+	prefix := "idx"
+	if instance.IsUnique == 1 {
+		prefix = "uk"
+	}
+	parts := []string{prefix, table.TableName}
+	for _, column := range columns {
+		parts = append(parts, column.ColumnName)
+	}
+	name := strings.Join(parts, "_")
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return name
+}
+
+func (s *tableIndexService) Remove(instance *model.TableIndex) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("id不能为空")
+	}
+	c, err := database.DB.Delete(instance)
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *tableIndexService) Update(instance *model.TableIndex) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("ID不能为空")
+	}
+	old := new(model.TableIndex)
+	if has, err := database.DB.ID(instance.Id).Get(old); err != nil {
+		return false, err
+	} else if !has {
+		return false, nil
+	}
+	// 不允许更改所属的表
+	merged := *old
+	if instance.IndexName != "" {
+		merged.IndexName = instance.IndexName
+	}
+	merged.IsUnique = instance.IsUnique
+	if len(instance.Columns) > 0 {
+		merged.Columns = instance.Columns
+	}
+	if _, err := s.validate(&merged); err != nil {
+		return false, err
+	}
+	if merged.IndexName != old.IndexName {
+		c, err := database.DB.Where("id <> ?", old.Id).Count(&model.TableIndex{
+			TableId:   old.TableId,
+			IndexName: merged.IndexName,
+		})
+		if err != nil {
+			return false, err
+		}
+		if c > 0 {
+			return false, errors.New("索引名已存在")
+		}
+	}
+
+	c, err := database.DB.ID(instance.Id).Cols("index_name", "is_unique", "columns").Update(&model.TableIndex{
+		// 允许更改的字段
+		IndexName: merged.IndexName,
+		IsUnique:  merged.IsUnique,
+		Columns:   merged.Columns,
+	})
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *tableIndexService) Get(instance *model.TableIndex) (*model.TableIndex, error) {
+	if instance.Id == "" {
+		return nil, errors.New("ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+func (s *tableIndexService) Paginate(condition *model.TableIndex, limit, offset int, orderBy string) (int, []*model.TableIndex, error) {
+	return s.PaginateBetweenTimes(condition, limit, offset, orderBy, nil)
+}
+
+func (s *tableIndexService) PaginateBetweenTimes(condition *model.TableIndex, limit, offset int, orderBy string, tcList map[string]*domain.TimeCondition) (int, []*model.TableIndex, error) {
+	// 处理不允许查询的字段
+	condition.Columns = nil
+
+	// 处理sql
+	session := database.DB.NewSession()
+	if limit > -1 && offset > -1 {
+		session.Limit(limit, offset)
+	}
+
+	if orderBy != "" {
+		session.OrderBy(orderBy)
+	}
+
+	// 处理时间字段，在某段时间之间
+	for tc, tr := range tcList {
+		if tc != "" {
+			if !tr.Start.IsZero() && !tr.End.IsZero() {
+				session.Where(tc+" between ? and ?", tr.Start, tr.End)
+			} else if tr.Start.IsZero() {
+				session.Where(tc+" <= ?", tr.End)
+			} else if tr.End.IsZero() {
+				session.Where(tc+" > ?", tr.Start)
+			}
+		}
+	}
+
+	// 模糊查找
+	if condition.IndexName != "" {
+		session.Where("index_name like ?", condition.IndexName+"%")
+		condition.IndexName = ""
+	}
+	var list []*model.TableIndex
+	total, err := session.FindAndCount(&list, condition)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(total), list, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/yockii/qscore/pkg/database"
+	"github.com/yockii/qscore/pkg/domain"
+	"github.com/yockii/qscore/pkg/util"
+
+	"github.com/yockii/quick-system/internal/model"
+)
+
+var TableRelationService = new(tableRelationService)
+
+type tableRelationService struct{}
+
+func (s *tableRelationService) Add(instance *model.TableRelation) (isDuplicated bool, success bool, err error) {
+	if instance.ApplicationId == "" {
+		return false, false, errors.New("应用ID不能为空")
+	}
+	if err = s.validate(instance); err != nil {
+		return
+	}
+	var c int64 = 0
+	c, err = database.DB.Count(&model.TableRelation{
+		SourceTableId:  instance.SourceTableId,
+		SourceColumnId: instance.SourceColumnId,
+		TargetTableId:  instance.TargetTableId,
+	})
+	if err != nil {
+		return
+	}
+	if c > 0 {
+		isDuplicated = true
+		return
+	}
+
+	instance.Id = model.TableRelationIdPrefix + util.GenerateDatabaseID()
+	if instance.OnDelete == 0 {
+		instance.OnDelete = model.OnDeleteRestrict
+	}
+	_, err = database.DB.Insert(instance)
+	success = err == nil
+	return
+}
+
+// validate 校验关联的表及字段均属于同一应用，且关联字段属于源表
+func (s *tableRelationService) validate(instance *model.TableRelation) error {
+	if instance.SourceTableId == "" || instance.TargetTableId == "" {
+		return errors.New("源表及目标表不能为空")
+	}
+	if instance.Cardinality < model.RelationOneToMany || instance.Cardinality > model.RelationManyToMany {
+		return errors.New("关联类型不正确")
+	}
+	if instance.OnDelete < 0 || instance.OnDelete > model.OnDeleteSetNull {
+		return errors.New("删除行为不正确")
+	}
+	if instance.SourceColumnId == "" && instance.Cardinality != model.RelationManyToMany {
+		return errors.New("一对多及多对一关联必须指定关联字段")
+	}
+	for _, tableId := range []string{instance.SourceTableId, instance.TargetTableId} {
+		table := new(model.TableConfig)
+		if has, err := database.DB.ID(tableId).Get(table); err != nil {
+			return err
+		} else if !has {
+			return parentNotFound("关联的表不存在")
+		}
+		if table.ApplicationId != instance.ApplicationId {
+			return parentMismatch("关联的表不属于该应用")
+		}
+	}
+	if instance.SourceColumnId != "" {
+		column := new(model.ColumnConfig)
+		if has, err := database.DB.ID(instance.SourceColumnId).Get(column); err != nil {
+			return err
+		} else if !has {
+			return parentNotFound("关联字段不存在")
+		}
+		if column.TableId != instance.SourceTableId {
+			return parentMismatch("关联字段不属于源表")
+		}
+	}
+	return nil
+}
+
+func (s *tableRelationService) Remove(instance *model.TableRelation) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("id不能为空")
+	}
+	c, err := database.DB.Delete(instance)
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *tableRelationService) Update(instance *model.TableRelation) (bool, error) {
+	if instance.Id == "" {
+		return false, errors.New("ID不能为空")
+	}
+	// 不允许更改的字段
+	old := new(model.TableRelation)
+	if has, err := database.DB.ID(instance.Id).Get(old); err != nil {
+		return false, err
+	} else if !has {
+		return false, nil
+	}
+	merged := *old
+	if instance.RelationName != "" {
+		merged.RelationName = instance.RelationName
+	}
+	if instance.SourceColumnId != "" {
+		merged.SourceColumnId = instance.SourceColumnId
+	}
+	if instance.TargetTableId != "" {
+		merged.TargetTableId = instance.TargetTableId
+	}
+	if instance.Cardinality != 0 {
+		merged.Cardinality = instance.Cardinality
+	}
+	if instance.OnDelete != 0 {
+		merged.OnDelete = instance.OnDelete
+	}
+	if err := s.validate(&merged); err != nil {
+		return false, err
+	}
+
+	c, err := database.DB.ID(instance.Id).Update(&model.TableRelation{
+		// 允许更改的字段
+		RelationName:   instance.RelationName,
+		SourceColumnId: instance.SourceColumnId,
+		TargetTableId:  instance.TargetTableId,
+		Cardinality:    instance.Cardinality,
+		OnDelete:       instance.OnDelete,
+		Comment:        instance.Comment,
+	})
+	if err != nil {
+		return false, err
+	}
+	if c == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *tableRelationService) Get(instance *model.TableRelation) (*model.TableRelation, error) {
+	if instance.Id == "" {
+		return nil, errors.New("ID不能为空")
+	}
+	has, err := database.DB.Get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+func (s *tableRelationService) Paginate(condition *model.TableRelation, limit, offset int, orderBy string) (int, []*model.TableRelation, error) {
+	return s.PaginateBetweenTimes(condition, limit, offset, orderBy, nil)
+}
+
+func (s *tableRelationService) PaginateBetweenTimes(condition *model.TableRelation, limit, offset int, orderBy string, tcList map[string]*domain.TimeCondition) (int, []*model.TableRelation, error) {
+	// 处理不允许查询的字段
+
+	// 处理sql
+	session := database.DB.NewSession()
+	if limit > -1 && offset > -1 {
+		session.Limit(limit, offset)
+	}
+
+	if orderBy != "" {
+		session.OrderBy(orderBy)
+	}
+
+	// 处理时间字段，在某段时间之间
+	for tc, tr := range tcList {
+		if tc != "" {
+			if !tr.Start.IsZero() && !tr.End.IsZero() {
+				session.Where(tc+" between ? and ?", tr.Start, tr.End)
+			} else if tr.Start.IsZero() {
+				session.Where(tc+" <= ?", tr.End)
+			} else if tr.End.IsZero() {
+				session.Where(tc+" > ?", tr.Start)
+			}
+		}
+	}
+
+	// 模糊查找
+	if condition.RelationName != "" {
+		session.Where("relation_name like ?", condition.RelationName+"%")
+		condition.RelationName = ""
+	}
+	var list []*model.TableRelation
+	total, err := session.FindAndCount(&list, condition)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(total), list, nil
+}
+
+// ListByApplication 列出应用的所有表关联
+func (s *tableRelationService) ListByApplication(applicationId string) ([]*model.TableRelation, error) {
+	if applicationId == "" {
+		return nil, errors.New("应用ID不能为空")
+	}
+	var list []*model.TableRelation
+	if err := database.DB.Find(&list, &model.TableRelation{ApplicationId: applicationId}); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+type diffOp struct {
+	kind byte // ' ' 相同 '-' 删除 '+' 新增
+	a, b int  // 在旧/新文本中的行号(从0开始)
+}
+
+// UnifiedDiff 生成两段文本按行比较的统一格式差异，无差异时返回空字符串
+func UnifiedDiff(fromName, toName, from, to string) string {
+	a := splitLines(from)
+	b := splitLines(to)
+	ops := myersDiff(a, b)
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	sb := new(strings.Builder)
+	sb.WriteString("--- " + fromName + "\n")
+	sb.WriteString("+++ " + toName + "\n")
+
+	for i := 0; i < len(ops); {
+		// 找到下一处变化
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i >= len(ops) {
+			break
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		// 向后扩展，直到连续相同的行超过上下文两倍
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			same := 0
+			for end+same < len(ops) && ops[end+same].kind == ' ' {
+				same++
+			}
+			if end+same >= len(ops) || same > diffContextLines*2 {
+				if same > diffContextLines {
+					same = diffContextLines
+				}
+				end += same
+				break
+			}
+			end += same
+		}
+		writeHunk(sb, a, b, ops[start:end])
+		i = end
+	}
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, a, b []string, ops []diffOp) {
+	aStart, bStart := -1, -1
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			if aStart < 0 {
+				aStart = op.a
+			}
+			aCount++
+		}
+		if op.kind != '-' {
+			if bStart < 0 {
+				bStart = op.b
+			}
+			bCount++
+		}
+	}
+	// 统一格式中行号从1开始，空范围时取前一行
+	if aStart < 0 {
+		aStart = ops[0].a - 1
+	}
+	if bStart < 0 {
+		bStart = ops[0].b - 1
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+	for _, op := range ops {
+		switch op.kind {
+		case '-':
+			sb.WriteString("-" + a[op.a] + "\n")
+		case '+':
+			sb.WriteString("+" + b[op.b] + "\n")
+		default:
+			sb.WriteString(" " + a[op.a] + "\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	return strings.Split(s, "\n")
+}
+
+// myersDiff 使用Myers算法计算最短编辑序列
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// 回溯得到编辑序列
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		vd := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && vd[offset+k-1] < vd[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := vd[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: ' ', a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{kind: '+', a: x, b: prevY})
+			} else {
+				ops = append(ops, diffOp{kind: '-', a: prevX, b: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
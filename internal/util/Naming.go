@@ -0,0 +1,36 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PascalCase 将下划线风格的名称转换为大驼峰，如 order_item -> OrderItem
+func PascalCase(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// CamelCase 将下划线风格的名称转换为小驼峰，如 order_item -> orderItem
+func CamelCase(name string) string {
+	s := PascalCase(name)
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+)
+
+// ReadZipFiles 将zip包内容读取为 文件路径->文件内容 的映射，忽略目录
+func ReadZipFiles(bs []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = content
+	}
+	return files, nil
+}